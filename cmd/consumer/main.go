@@ -4,13 +4,21 @@ import (
 	"biletter-service/internal/config"
 	"biletter-service/internal/repository"
 	"biletter-service/internal/services"
+	"biletter-service/pkg/broker"
+	"biletter-service/pkg/cache"
 	"biletter-service/pkg/database"
 	"biletter-service/pkg/logger"
+	"biletter-service/pkg/metrics"
+	"biletter-service/pkg/tracing"
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -19,19 +27,46 @@ func main() {
 	zapLogger := logger.New(cfg.LogLevel)
 	defer zapLogger.Sync()
 
+	shutdownTracing, err := tracing.Init(cfg.Tracing, "biletter-consumer")
+	if err != nil {
+		log.Fatal("Failed to init tracing:", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
 	db, err := database.New(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	defer db.Close()
+	metrics.RegisterDB(db, "biletter")
 
 	repos := repository.New(db)
 
+	// Redis для read model: кеш мест и счетчики аналитики
+	cacheClient := cache.NewRedisCache(cfg.Redis)
+	defer cacheClient.Close()
+
+	brokers, err := broker.NewFactory(cfg, db)
+	if err != nil {
+		log.Fatal("Failed to configure broker:", err)
+	}
+	if brokers.InProcess() || brokers.Type() == broker.TypeNone {
+		log.Fatalf("Broker type %s is not supported by standalone consumer", brokers.Type())
+	}
+
 	// Создаем consumer service
 	consumerService, err := services.NewConsumerService(
+		brokers,
 		cfg.Kafka,
 		cfg.Kafka.ConsumerGroup,
 		repos,
+		cacheClient,
 		zapLogger,
 	)
 	if err != nil {
@@ -48,6 +83,13 @@ func main() {
 
 	log.Println("Consumer started successfully")
 
+	healthServer := newHealthServer(cfg.Kafka.HealthAddr, consumerService)
+	go func() {
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Health server failed: %v", err)
+		}
+	}()
+
 	// Ожидаем сигналы завершения
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -58,10 +100,47 @@ func main() {
 	// Отменяем контекст
 	cancel()
 
-	// Останавливаем consumer service
+	// Останавливаем consumer service; пока начатые сообщения дообрабатываются,
+	// /health/ready отдает 503
 	if err := consumerService.Stop(); err != nil {
 		log.Printf("Error stopping consumer service: %v", err)
 	}
 
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error stopping health server: %v", err)
+	}
+
 	log.Println("Consumer stopped")
 }
+
+// newHealthServer отдает /health/live (процесс жив, подписка не остановлена),
+// /health/ready (consumer получил партиции и обрабатывает сообщения) и /metrics
+func newHealthServer(addr string, consumerService *services.ConsumerService) *http.Server {
+	writeHealth := func(w http.ResponseWriter, ok bool) {
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(consumerService.Health())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/live", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, consumerService.Live())
+	})
+	mux.HandleFunc("/health/ready", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, consumerService.Ready())
+	})
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
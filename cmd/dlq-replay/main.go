@@ -0,0 +1,42 @@
+package main
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/pkg/broker"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	limit := flag.Int("limit", 0, "maximum number of messages to replay (0 - all)")
+	dryRun := flag.Bool("dry-run", false, "print messages without replaying them")
+	target := flag.String("topic", "", "target topic (default: original topic of each message)")
+	group := flag.String("group", "", "consumer group that stores replay progress (default: <consumer_group>-dlq-replay)")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	groupID := *group
+	if groupID == "" {
+		groupID = cfg.Kafka.ConsumerGroup + "-dlq-replay"
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	replayed, err := broker.ReplayDeadLetters(ctx, cfg.Kafka, broker.DLQReplayOptions{
+		GroupID:     groupID,
+		TargetTopic: *target,
+		Limit:       *limit,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("DLQ replay failed after %d messages: %v", replayed, err)
+	}
+
+	log.Printf("DLQ replay finished: %d messages from %s", replayed, cfg.Kafka.Topics.DeadLetter)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/pkg/paymentmock"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Заглушка платежного шлюза. Сервис подключается к ней через
+// PAYMENT_GATEWAY_URL=http://localhost:8091; teamSlug и пароль по умолчанию
+// берутся из той же конфигурации, что и у сервиса, поэтому токены совпадают.
+func main() {
+	cfg := config.Load()
+
+	addr := flag.String("addr", ":8091", "listen address")
+	publicURL := flag.String("public-url", "", "mock address used in paymentURL (default: from the request Host)")
+	teamSlug := flag.String("team-slug", cfg.Payment.TeamSlug, "expected teamSlug")
+	password := flag.String("password", cfg.Payment.Password, "password used to verify tokens")
+	outcome := flag.String("outcome", paymentmock.StatusConfirmed, "payment outcome: CONFIRMED, AUTHORIZED or FAILED")
+	autoPay := flag.Bool("auto-pay", false, "pay every payment right after init, without the payment page")
+	delay := flag.Duration("notification-delay", time.Second, "delay before the webhook is sent")
+	retries := flag.Int("notification-retries", 3, "webhook retries on delivery errors and non-2xx responses")
+	flag.Parse()
+
+	server := paymentmock.New(paymentmock.Options{
+		TeamSlug:  *teamSlug,
+		Password:  *password,
+		PublicURL: *publicURL,
+	})
+	err := server.SetSettings(paymentmock.Settings{
+		Outcome:             *outcome,
+		AutoPay:             *autoPay,
+		NotificationDelay:   *delay,
+		NotificationRetries: *retries,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Payment gateway mock listening on %s (team %s)", *addr, *teamSlug)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %s", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error stopping payment gateway mock: %v", err)
+	}
+	server.Close()
+}
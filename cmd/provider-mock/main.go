@@ -0,0 +1,67 @@
+package main
+
+import (
+	"biletter-service/pkg/providermock"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Заглушка провайдера мероприятий Hackload. Сервис подключается к ней через
+// HACKLOAD_BASE_URL=http://localhost:8090
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	apiVersion := flag.String("api-version", "v1", "partner API version in /api/partners/{version}")
+	rows := flag.Int("rows", 10, "rows in the hall")
+	seatsPerRow := flag.Int("seats", 20, "seats per row")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	jitter := flag.Duration("jitter", 0, "random extra delay, up to this value")
+	failureRate := flag.Float64("failure-rate", 0, "share of requests that fail with -failure-status (0..1)")
+	failureStatus := flag.Int("failure-status", http.StatusServiceUnavailable, "status of injected failures")
+	flag.Parse()
+
+	if *failureRate < 0 || *failureRate > 1 {
+		log.Fatal("-failure-rate must be between 0 and 1")
+	}
+
+	server := providermock.New(providermock.Options{
+		APIVersion:  *apiVersion,
+		Rows:        *rows,
+		SeatsPerRow: *seatsPerRow,
+		Faults: providermock.Faults{
+			Latency:       *latency,
+			LatencyJitter: *jitter,
+			FailureRate:   *failureRate,
+			FailureStatus: *failureStatus,
+		},
+	})
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Provider mock listening on %s (%d places)", *addr, (*rows)*(*seatsPerRow))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %s", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error stopping provider mock: %v", err)
+	}
+}
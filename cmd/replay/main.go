@@ -0,0 +1,242 @@
+package main
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/domain_events"
+	"biletter-service/internal/eventschema"
+	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
+	"biletter-service/internal/repository"
+	"biletter-service/internal/services"
+	"biletter-service/pkg/broker"
+	"biletter-service/pkg/cache"
+	"biletter-service/pkg/database"
+	"biletter-service/pkg/logger"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Источники событий для проигрывания
+const (
+	sourceKafka = "kafka"
+	sourceLog   = "log"
+)
+
+func main() {
+	source := flag.String("source", sourceKafka, "event source: kafka (booking_events topic) or log (booking_event_log table)")
+	projection := flag.String("projection", string(domain_events.ProjectionReadModel), "projection to rebuild: read_model or history")
+	group := flag.String("group", "", "name that stores replay progress and processed events (default: <consumer_group>-replay-<projection>)")
+	from := flag.String("from", "", "replay events starting at this time (RFC3339)")
+	fromOffset := flag.Int64("offset", -1, "kafka: start offset in every partition")
+	fromID := flag.Int64("from-id", 0, "log: start after this booking_event_log id")
+	restart := flag.Bool("restart", false, "ignore saved progress and start from -offset/-from-id/-from")
+	reset := flag.Bool("reset", false, "read_model: clear analytics counters and seat cache before replay")
+	limit := flag.Int("limit", 0, "maximum number of events to replay (0 - all)")
+	dryRun := flag.Bool("dry-run", false, "validate events against their schemas without applying them or saving progress")
+	skipErrors := flag.Bool("skip-errors", false, "skip events that fail instead of stopping")
+	progressInterval := flag.Duration("progress-interval", 5*time.Second, "how often to report progress")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	zapLogger := logger.New(cfg.LogLevel)
+	defer zapLogger.Sync()
+
+	var fromTime time.Time
+	if *from != "" {
+		parsed, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			log.Fatalf("Invalid -from: %v", err)
+		}
+		fromTime = parsed
+	}
+
+	groupID := *group
+	if groupID == "" {
+		groupID = fmt.Sprintf("%s-replay-%s", cfg.Kafka.ConsumerGroup, *projection)
+	}
+
+	if *source != sourceKafka && *source != sourceLog {
+		log.Fatalf("Unknown source %q", *source)
+	}
+	if *source == sourceLog && domain_events.Projection(*projection) == domain_events.ProjectionHistory {
+		log.Fatal("History projection can not be rebuilt from itself, use -source kafka")
+	}
+
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	repos := repository.New(db)
+
+	// Redis для read model: кеш мест и счетчики аналитики
+	cacheClient := cache.NewRedisCache(cfg.Redis)
+	defer cacheClient.Close()
+
+	// Обработчик отмечает события в processed_events под именем groupID:
+	// повторный запуск после сбоя не применит событие дважды
+	handler, err := domain_events.NewHandlers(repos, cacheClient, groupID, zapLogger).
+		GetProjectionHandler(domain_events.Projection(*projection))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dryRun {
+		handler = validateHandler()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if *reset && !*dryRun {
+		if domain_events.Projection(*projection) != domain_events.ProjectionReadModel {
+			log.Fatal("-reset is supported only for read_model projection")
+		}
+		if err := readmodel.Reset(ctx, cacheClient); err != nil {
+			log.Fatal("Failed to reset read model:", err)
+		}
+		log.Println("Read model cleared")
+	}
+
+	// Отметки прошлых запусков под этим именем иначе отбросили бы все события как повторные,
+	// и перестроенная проекция осталась бы пустой
+	if (*reset || *restart) && !*dryRun {
+		deleted, err := repos.ProcessedEvent.DeleteByGroup(ctx, groupID)
+		if err != nil {
+			log.Fatal("Failed to clear processed events:", err)
+		}
+		log.Printf("Cleared %d processed events of group %s", deleted, groupID)
+	}
+
+	progress := newProgressReporter(*source, *dryRun)
+	stopReporting := progress.start(*progressInterval)
+
+	log.Printf("Replaying %s into %s projection (group %s, dry-run %t)", *source, *projection, groupID, *dryRun)
+
+	var replayed int
+	switch *source {
+	case sourceKafka:
+		replayed, err = broker.ReplayTopic(ctx, cfg.Kafka, cfg.Kafka.Topics.BookingEvents, handler, broker.ReplayOptions{
+			GroupID:    groupID,
+			FromOffset: *fromOffset,
+			From:       fromTime,
+			Restart:    *restart,
+			Limit:      *limit,
+			DryRun:     *dryRun,
+			SkipErrors: *skipErrors,
+			Progress: func(position broker.ReplayPosition, event *models.DomainEvent, err error) {
+				progress.record(fmt.Sprintf("%s/%d/%d of %d", position.Topic, position.Partition, position.Offset, position.HighWatermark), err)
+			},
+		})
+	case sourceLog:
+		replayed, err = services.NewReplayService(repos, zapLogger).ReplayEventLog(ctx, handler, services.EventLogReplayOptions{
+			Checkpoint: groupID,
+			FromID:     *fromID,
+			From:       fromTime,
+			Restart:    *restart,
+			Limit:      *limit,
+			DryRun:     *dryRun,
+			SkipErrors: *skipErrors,
+			Progress: func(entry models.BookingEventLogEntry, err error) {
+				progress.record(fmt.Sprintf("booking_event_log id %d", entry.ID), err)
+			},
+		})
+	}
+
+	stopReporting()
+	if err != nil {
+		log.Fatalf("Replay stopped after %d events: %v (run again to resume)", replayed, err)
+	}
+
+	log.Printf("Replay finished: %d events, %d failed", replayed, progress.failedCount())
+}
+
+// validateHandler проверяет события по схемам без изменения проекций (режим dry-run)
+func validateHandler() broker.EventHandler {
+	return broker.EventHandlerFunc(func(ctx context.Context, event *models.DomainEvent) error {
+		if err := eventschema.Default.Validate(event); err != nil {
+			return broker.Permanent(err)
+		}
+		return nil
+	})
+}
+
+// progressReporter периодически выводит количество проигранных событий и текущую позицию
+type progressReporter struct {
+	source  string
+	dryRun  bool
+	started time.Time
+
+	mu       sync.Mutex
+	replayed int
+	failed   int
+	position string
+}
+
+func newProgressReporter(source string, dryRun bool) *progressReporter {
+	return &progressReporter{source: source, dryRun: dryRun, started: time.Now()}
+}
+
+func (p *progressReporter) record(position string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.replayed++
+	if err != nil {
+		p.failed++
+	}
+	p.position = position
+}
+
+func (p *progressReporter) failedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failed
+}
+
+func (p *progressReporter) report() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elapsed := time.Since(p.started)
+	rate := float64(p.replayed) / elapsed.Seconds()
+	log.Printf("Replay progress (%s, dry-run %t): %d events, %d failed, %.0f events/s, position %s",
+		p.source, p.dryRun, p.replayed, p.failed, rate, p.position)
+}
+
+// start запускает вывод прогресса; возвращаемая функция останавливает его и выводит итог
+func (p *progressReporter) start(interval time.Duration) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p.report()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+		p.report()
+	}
+}
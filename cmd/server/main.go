@@ -3,13 +3,19 @@ package main
 import (
 	"biletter-service/internal/config"
 	"biletter-service/internal/handlers"
+	"biletter-service/internal/middleware"
 	"biletter-service/internal/repository"
 	"biletter-service/internal/services"
 	"biletter-service/pkg/broker"
 	"biletter-service/pkg/cache"
 	"biletter-service/pkg/database"
+	"biletter-service/pkg/health"
 	"biletter-service/pkg/logger"
+	"biletter-service/pkg/metrics"
+	"biletter-service/pkg/tracing"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -22,19 +28,39 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"go.uber.org/zap"
 )
 
+// healthCheckTimeout ограничивает каждую проверку /health/ready
+const healthCheckTimeout = 2 * time.Second
+
 func main() {
 	cfg := config.Load()
+	if err := cfg.Auth.Validate(); err != nil {
+		log.Fatal("Invalid auth config:", err)
+	}
 
 	zapLogger := logger.New(cfg.LogLevel)
 	defer zapLogger.Sync()
 
+	shutdownTracing, err := tracing.Init(cfg.Tracing, "biletter-server")
+	if err != nil {
+		log.Fatal("Failed to init tracing:", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
 	db, err := database.New(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	defer db.Close()
+	metrics.RegisterDB(db, "biletter")
 
 	if err := runMigrations(cfg.Database); err != nil {
 		log.Fatal("Failed to run migrations:", err)
@@ -43,27 +69,67 @@ func main() {
 	// Создаем cache клиент
 	cacheClient := cache.NewRedisCache(cfg.Redis)
 
-	// Создаем event publisher
-	var eventPublisher broker.Publisher
-	if len(cfg.Kafka.Brokers) > 0 {
-		if publisher, err := broker.NewKafkaPublisher(cfg.Kafka); err == nil {
-			eventPublisher = publisher
-			defer eventPublisher.Close()
-		} else {
-			log.Printf("Failed to create Kafka publisher: %v", err)
-		}
+	// Создаем event publisher выбранного брокера; отключить события можно только явно через broker.type = none
+	brokers, err := broker.NewFactory(cfg, db)
+	if err != nil {
+		log.Fatal("Failed to configure broker:", err)
 	}
+	defer brokers.Close()
 
+	eventPublisher, err := brokers.NewPublisher()
+	if err != nil {
+		log.Fatalf("Failed to create %s publisher: %v", brokers.Type(), err)
+	}
+	if eventPublisher != nil {
+		defer eventPublisher.Close()
+	} else {
+		log.Println("Event publishing is disabled (broker.type = none)")
+	}
+
+	repository.ConfigureUserCache(cfg.UserCache)
 	repos := repository.New(db)
-	services := services.New(repos, cacheClient, eventPublisher, cfg, zapLogger)
-	handlers := handlers.New(services, zapLogger)
 
-	if err := repos.InitializeCache(); err != nil {
-		log.Fatal("Failed to initialize cache:", err)
+	// Брокер в памяти доступен только этому процессу, поэтому consumer запускается здесь же
+	var consumerService *services.ConsumerService
+	if brokers.InProcess() {
+		consumerService, err = services.NewConsumerService(brokers, cfg.Kafka, cfg.Kafka.ConsumerGroup, repos, cacheClient, zapLogger)
+		if err != nil {
+			log.Fatal("Failed to create consumer service:", err)
+		}
+		if err := consumerService.Start(context.Background()); err != nil {
+			log.Fatal("Failed to start consumer service:", err)
+		}
+		defer consumerService.Stop()
+	}
+	services := services.New(repos, cacheClient, eventPublisher, cfg, zapLogger)
+	handlers := handlers.New(services, handlers.Middlewares{
+		RateLimiter: middleware.NewRateLimiter(cacheClient, cfg.RateLimit, zapLogger),
+		Idempotency: middleware.NewIdempotency(cacheClient, cfg.Idempotency, zapLogger),
+		Timeouts:    middleware.NewTimeouts(cfg.Timeouts, zapLogger),
+		Admin:       middleware.RequireAdmin(),
+	}, newHealthChecker(db, cacheClient, eventPublisher, brokers.Type(), repos, consumerService), zapLogger)
+
+	// Кэш прогревается в фоне: сервер уже отвечает на /health/live,
+	// а /health/ready отдает 503, пока предзагрузка не завершится
+	warmCtx, stopWarm := context.WithCancel(context.Background())
+	defer stopWarm()
+	go warmCache(warmCtx, repos, zapLogger)
+
+	// Синхронизируем кэш пользователей между репликами
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	if err := repos.StartUserCacheSync(syncCtx, cacheClient, cfg.UserCache.InvalidationChannel, zapLogger); err != nil {
+		log.Printf("User cache sync disabled: %v", err)
 	}
 
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	// Без доверенных прокси X-Forwarded-For игнорируется: иначе клиент подменяет свой IP
+	// для лимитов запросов и исключений rate_limit.exempt_networks
+	if err := router.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		log.Fatal("Invalid app.trusted_proxies:", err)
+	}
+	// Логгер запроса выше Recovery, чтобы паника попала в access-лог как 500
+	router.Use(middleware.Tracing("biletter-server"), middleware.RequestLogger(zapLogger), gin.Recovery(), middleware.Metrics())
 
 	handlers.RegisterRoutes(router)
 
@@ -94,9 +160,7 @@ func runMigrations(cfg config.Database) error {
 	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
 		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, cfg.SSLMode)
 
-	m, err := migrate.New(
-		"file://migrations",
-		databaseURL)
+	m, err := migrate.New(database.MigrationsSource, databaseURL)
 	if err != nil {
 		return fmt.Errorf("failed to create migrate instance: %w", err)
 	}
@@ -109,3 +173,83 @@ func runMigrations(cfg config.Database) error {
 	log.Println("Migrations applied successfully")
 	return nil
 }
+
+// warmCache предзагружает кэши, повторяя попытку при ошибке
+func warmCache(ctx context.Context, repos *repository.Repository, logger *zap.Logger) {
+	for {
+		err := repos.InitializeCache(ctx)
+		if err == nil {
+			logger.Info("Cache preloaded", zap.Int("users", repos.UserCacheSize()))
+			return
+		}
+		logger.Error("Failed to initialize cache", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// newHealthChecker собирает проверки /health/ready. Брокер проверяется как необязательная
+// зависимость: при его недоступности события уходят в outbox, а запросы обслуживаются.
+func newHealthChecker(db *sql.DB, cacheClient cache.Cache, publisher broker.Publisher, brokerType string,
+	repos *repository.Repository, consumerService *services.ConsumerService) *health.Checker {
+	checker := health.NewChecker("biletter-service", healthCheckTimeout)
+
+	checker.Add("postgres", func(ctx context.Context) (string, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return "", err
+		}
+		stats := db.Stats()
+		return fmt.Sprintf("open=%d in_use=%d", stats.OpenConnections, stats.InUse), nil
+	})
+
+	checker.Add("redis", func(ctx context.Context) (string, error) {
+		return "", cacheClient.Ping(ctx)
+	})
+
+	latestMigration, latestErr := database.LatestMigration(database.MigrationsSource)
+	checker.Add("migrations", func(ctx context.Context) (string, error) {
+		version, dirty, err := database.MigrationVersion(ctx, db)
+		if err != nil {
+			return "", err
+		}
+		details := fmt.Sprintf("version=%d", version)
+		switch {
+		case dirty:
+			return details, fmt.Errorf("migration %d is dirty", version)
+		case latestErr != nil:
+			return details, latestErr
+		case version < latestMigration:
+			return details, fmt.Errorf("schema version %d is behind migrations %d", version, latestMigration)
+		}
+		return details, nil
+	})
+
+	checker.Add("user_cache", func(ctx context.Context) (string, error) {
+		details := fmt.Sprintf("users=%d", repos.UserCacheSize())
+		if !repos.CacheWarm() {
+			return details, errors.New("user cache preload is not finished")
+		}
+		return details, nil
+	})
+
+	if pinger, ok := publisher.(broker.Pinger); ok {
+		checker.AddOptional("broker", func(ctx context.Context) (string, error) {
+			return brokerType, pinger.Ping(ctx)
+		})
+	}
+
+	if consumerService != nil {
+		checker.Add("consumer", func(ctx context.Context) (string, error) {
+			if !consumerService.Ready() {
+				return "", errors.New("consumer is not ready")
+			}
+			return "", nil
+		})
+	}
+
+	return checker
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,10 +14,17 @@ type Config struct {
 	Database        Database        `mapstructure:"database"`
 	Redis           Redis           `mapstructure:"redis"`
 	Kafka           Kafka           `mapstructure:"kafka"`
+	Broker          Broker          `mapstructure:"broker"`
 	External        External        `mapstructure:"external"`
 	ExternalService ExternalService `mapstructure:"external_service"`
 	Payment         Payment         `mapstructure:"payment"`
 	App             App             `mapstructure:"app"`
+	Auth            Auth            `mapstructure:"auth"`
+	UserCache       UserCache       `mapstructure:"user_cache"`
+	RateLimit       RateLimit       `mapstructure:"rate_limit"`
+	Idempotency     Idempotency     `mapstructure:"idempotency"`
+	Tracing         Tracing         `mapstructure:"tracing"`
+	Timeouts        Timeouts        `mapstructure:"timeouts"`
 }
 
 type Database struct {
@@ -36,13 +45,60 @@ type Redis struct {
 }
 
 type Kafka struct {
-	Brokers       []string `mapstructure:"brokers"`
-	Topics        Topics   `mapstructure:"topics"`
-	ConsumerGroup string   `mapstructure:"consumer_group"`
+	Brokers       []string      `mapstructure:"brokers"`
+	Topics        Topics        `mapstructure:"topics"`
+	ConsumerGroup string        `mapstructure:"consumer_group"`
+	Retry         KafkaRetry    `mapstructure:"retry"`
+	Producer      KafkaProducer `mapstructure:"producer"`
+	// Обработчики партиции consumer'а: разные ключи обрабатываются параллельно, один ключ - по порядку
+	Workers int `mapstructure:"workers"`
+	// Сколько при остановке или ребалансировке ждать завершения сообщений, обработка которых уже началась
+	DrainTimeout time.Duration `mapstructure:"drain_timeout"`
+	// Адрес HTTP сервера проверок здоровья отдельного consumer'а (cmd/consumer)
+	HealthAddr string `mapstructure:"health_addr"`
+	// Хранение отметок об обработанных событиях для идемпотентности consumer'а
+	ProcessedEventsTTL             time.Duration `mapstructure:"processed_events_ttl"`
+	ProcessedEventsCleanupInterval time.Duration `mapstructure:"processed_events_cleanup_interval"`
+}
+
+// Broker выбор реализации брокера сообщений. Топики, consumer group и политика
+// повторов берутся из секции kafka для всех реализаций.
+type Broker struct {
+	Type     string         `mapstructure:"type"` // kafka, postgres, memory или none
+	Postgres BrokerPostgres `mapstructure:"postgres"`
+}
+
+// BrokerPostgres настройки очереди сообщений в таблице Postgres
+type BrokerPostgres struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // опрос таблицы, если NOTIFY не пришел
+	BatchSize    int           `mapstructure:"batch_size"`
+	Retention    time.Duration `mapstructure:"retention"` // хранение сообщений после публикации
 }
 
 type Topics struct {
 	BookingEvents string `mapstructure:"booking_events"`
+	DeadLetter    string `mapstructure:"dead_letter"`
+}
+
+// KafkaProducer режим публикации событий
+type KafkaProducer struct {
+	Mode           string        `mapstructure:"mode"`            // sync или async
+	FlushFrequency time.Duration `mapstructure:"flush_frequency"` // linger: максимальное ожидание наполнения пачки
+	FlushMessages  int           `mapstructure:"flush_messages"`  // размер пачки в сообщениях
+	FlushBytes     int           `mapstructure:"flush_bytes"`     // размер пачки в байтах
+	Compression    string        `mapstructure:"compression"`     // none, gzip, snappy, lz4, zstd
+	BufferSize     int           `mapstructure:"buffer_size"`     // сообщений в памяти в ожидании отправки
+	RetryMax       int           `mapstructure:"retry_max"`       // повторы отправки внутри producer'а
+	// Недоставленные сообщения сохраняются в outbox и переотправляются с этим интервалом
+	OutboxRelayInterval time.Duration `mapstructure:"outbox_relay_interval"`
+}
+
+// KafkaRetry политика повторной обработки сообщений consumer'ом
+type KafkaRetry struct {
+	Attempts   int             `mapstructure:"attempts"`    // попыток в процессе, включая первую
+	Backoff    time.Duration   `mapstructure:"backoff"`     // начальная пауза между попытками
+	MaxBackoff time.Duration   `mapstructure:"max_backoff"` // верхняя граница паузы
+	Delays     []time.Duration `mapstructure:"delays"`      // задержки цепочки retry топиков <topic>.retry.N
 }
 
 type External struct {
@@ -54,18 +110,94 @@ type ExternalService struct {
 }
 
 type HackloadConfig struct {
-	BaseURL    string `mapstructure:"base_url"`
-	APIVersion string `mapstructure:"api_version"`
+	BaseURL    string     `mapstructure:"base_url"`
+	APIVersion string     `mapstructure:"api_version"`
+	Client     HTTPClient `mapstructure:"client"`
 }
 
 type Payment struct {
-	GatewayURL string `mapstructure:"gateway_url"`
-	TeamSlug   string `mapstructure:"team_slug"`
-	Password   string `mapstructure:"password"`
+	GatewayURL string     `mapstructure:"gateway_url"`
+	TeamSlug   string     `mapstructure:"team_slug"`
+	Password   string     `mapstructure:"password"`
+	Client     HTTPClient `mapstructure:"client"`
+}
+
+// HTTPClient настройки клиента внешнего сервиса
+type HTTPClient struct {
+	Timeout         time.Duration `mapstructure:"timeout"`            // на одну попытку запроса
+	MaxRetries      int           `mapstructure:"max_retries"`        // повторы идемпотентных запросов
+	RetryBaseDelay  time.Duration `mapstructure:"retry_base_delay"`   // задержка перед первым повтором
+	RetryMaxDelay   time.Duration `mapstructure:"retry_max_delay"`    // верхняя граница задержки
+	BreakerFailures int           `mapstructure:"breaker_failures"`   // ошибок подряд до размыкания, 0 - без breaker'а
+	BreakerTimeout  time.Duration `mapstructure:"breaker_timeout"`    // сколько breaker разомкнут до пробного запроса
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`     // соединений в пуле на upstream
+	MaxConnsPerHost int           `mapstructure:"max_conns_per_host"` // 0 - без ограничения
+	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout"`
 }
 
 type App struct {
 	URL string `mapstructure:"url"`
+	// Прокси, которым доверяется X-Forwarded-For/X-Real-IP при определении IP клиента.
+	// Пусто - IP клиента берется из адреса соединения.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+type Auth struct {
+	JWTSecret       string        `mapstructure:"jwt_secret"`
+	Issuer          string        `mapstructure:"issuer"`
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+}
+
+// minJWTSecretLength минимальная длина секрета подписи токенов (HS256 - 256 бит)
+const minJWTSecretLength = 32
+
+// Validate проверяет секрет подписи: без него любой может подделать токен
+func (a Auth) Validate() error {
+	if len(a.JWTSecret) < minJWTSecretLength {
+		return fmt.Errorf("auth.jwt_secret (AUTH_JWT_SECRET) must be at least %d bytes", minJWTSecretLength)
+	}
+	return nil
+}
+
+type UserCache struct {
+	Capacity            int           `mapstructure:"capacity"`
+	TTL                 time.Duration `mapstructure:"ttl"`
+	InvalidationChannel string        `mapstructure:"invalidation_channel"`
+}
+
+type RateLimit struct {
+	Enabled        bool                     `mapstructure:"enabled"`
+	ExemptRoles    []string                 `mapstructure:"exempt_roles"` // роли из users.role без лимитов
+	ExemptNetworks []string                 `mapstructure:"exempt_networks"`
+	Groups         map[string]RateLimitRule `mapstructure:"groups"`
+}
+
+// RateLimitRule параметры token bucket для группы маршрутов
+type RateLimitRule struct {
+	Rate  float64 `mapstructure:"rate"`  // пополнение, запросов в секунду
+	Burst int     `mapstructure:"burst"` // емкость корзины
+	By    string  `mapstructure:"by"`    // "user" или "ip"
+}
+
+type Idempotency struct {
+	TTL     time.Duration `mapstructure:"ttl"`      // сколько хранится сохраненный ответ
+	LockTTL time.Duration `mapstructure:"lock_ttl"` // сколько держится блокировка выполняющегося запроса
+}
+
+// Timeouts дедлайны обработки запросов. Default действует для всего /api,
+// Groups задает дедлайн для отдельных групп маршрутов
+type Timeouts struct {
+	Default time.Duration            `mapstructure:"default"`
+	Groups  map[string]time.Duration `mapstructure:"groups"`
+}
+
+// Tracing экспорт трассировок OpenTelemetry по OTLP/HTTP
+type Tracing struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`     // host:port OTLP/HTTP коллектора
+	Insecure    bool    `mapstructure:"insecure"`     // без TLS, для локального коллектора
+	SampleRatio float64 `mapstructure:"sample_ratio"` // доля трассировок, начинающихся в сервисе
 }
 
 func Load() *Config {
@@ -91,13 +223,74 @@ func Load() *Config {
 	viper.SetDefault("redis.db", 0)
 	viper.SetDefault("kafka.brokers", []string{"biletter-kafka:29092"})
 	viper.SetDefault("kafka.topics.booking_events", "booking_events")
+	viper.SetDefault("kafka.topics.dead_letter", "booking_events.dlq")
 	viper.SetDefault("kafka.consumer_group", "biletter-app")
+	viper.SetDefault("kafka.workers", 8)
+	viper.SetDefault("kafka.drain_timeout", "30s")
+	viper.SetDefault("kafka.health_addr", ":8082")
+	viper.SetDefault("kafka.producer.mode", "sync")
+	viper.SetDefault("kafka.producer.flush_frequency", "10ms")
+	viper.SetDefault("kafka.producer.flush_messages", 100)
+	viper.SetDefault("kafka.producer.flush_bytes", 1048576)
+	viper.SetDefault("kafka.producer.compression", "snappy")
+	viper.SetDefault("kafka.producer.buffer_size", 10000)
+	viper.SetDefault("kafka.producer.retry_max", 3)
+	viper.SetDefault("kafka.producer.outbox_relay_interval", "5s")
+	viper.SetDefault("kafka.processed_events_ttl", "168h")
+	viper.SetDefault("kafka.processed_events_cleanup_interval", "1h")
+	viper.SetDefault("kafka.retry.attempts", 3)
+	viper.SetDefault("kafka.retry.backoff", "200ms")
+	viper.SetDefault("kafka.retry.max_backoff", "5s")
+	viper.SetDefault("kafka.retry.delays", []string{"30s", "5m"})
+	viper.SetDefault("broker.type", "kafka")
+	viper.SetDefault("broker.postgres.poll_interval", "1s")
+	viper.SetDefault("broker.postgres.batch_size", 100)
+	viper.SetDefault("broker.postgres.retention", "168h")
 	viper.SetDefault("external.hackload_base_url", "http://localhost:8080")
 	viper.SetDefault("external_service.hackload.base_url", "http://localhost:8080")
 	viper.SetDefault("external_service.hackload.api_version", "v1")
 	viper.SetDefault("payment.gateway_url", "https://hub.hackload.kz/payment-provider/common/api/v1")
 	viper.SetDefault("payment.team_slug", "metaload-akbori")
+	for _, prefix := range []string{"external_service.hackload.client", "payment.client"} {
+		viper.SetDefault(prefix+".timeout", "10s")
+		viper.SetDefault(prefix+".max_retries", 2)
+		viper.SetDefault(prefix+".retry_base_delay", "100ms")
+		viper.SetDefault(prefix+".retry_max_delay", "2s")
+		viper.SetDefault(prefix+".breaker_failures", 5)
+		viper.SetDefault(prefix+".breaker_timeout", "30s")
+		viper.SetDefault(prefix+".max_idle_conns", 100)
+		viper.SetDefault(prefix+".max_conns_per_host", 0)
+		viper.SetDefault(prefix+".idle_conn_timeout", "90s")
+	}
+	// Создание платежа в шлюзе может занимать больше обычного
+	viper.SetDefault("payment.client.timeout", "30s")
 	viper.SetDefault("app.url", "http://localhost:8081")
+	viper.SetDefault("app.trusted_proxies", []string{})
+	viper.SetDefault("auth.issuer", "biletter-service")
+	viper.SetDefault("auth.access_token_ttl", "15m")
+	viper.SetDefault("auth.refresh_token_ttl", "168h")
+	viper.SetDefault("user_cache.capacity", 100000)
+	viper.SetDefault("user_cache.ttl", "30m")
+	viper.SetDefault("user_cache.invalidation_channel", "user.invalidate")
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.lock_ttl", "1m")
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("timeouts.default", "10s")
+	viper.SetDefault("timeouts.groups", map[string]interface{}{
+		"payments": "40s",
+		"admin":    "30s",
+		"reset":    "2m",
+	})
+	viper.SetDefault("rate_limit.enabled", false)
+	viper.SetDefault("rate_limit.exempt_roles", []string{"service"})
+	viper.SetDefault("rate_limit.exempt_networks", []string{})
+	viper.SetDefault("rate_limit.groups", map[string]interface{}{
+		"seats_select": map[string]interface{}{"rate": 10, "burst": 20, "by": "user"},
+		"events":       map[string]interface{}{"rate": 50, "burst": 100, "by": "ip"},
+	})
 
 	// Привязываем переменные окружения
 	viper.BindEnv("port", "PORT")
@@ -111,12 +304,31 @@ func Load() *Config {
 	viper.BindEnv("redis.db", "REDIS_DB")
 	viper.BindEnv("kafka.brokers", "KAFKA_BROKERS")
 	viper.BindEnv("kafka.topics.booking_events", "KAFKA_TOPICS_BOOKING_EVENTS")
+	viper.BindEnv("kafka.topics.dead_letter", "KAFKA_TOPICS_DEAD_LETTER")
 	viper.BindEnv("kafka.consumer_group", "KAFKA_CONSUMER_GROUP")
+	viper.BindEnv("kafka.workers", "KAFKA_CONSUMER_WORKERS")
+	viper.BindEnv("kafka.drain_timeout", "KAFKA_CONSUMER_DRAIN_TIMEOUT")
+	viper.BindEnv("kafka.health_addr", "CONSUMER_HEALTH_ADDR")
+	viper.BindEnv("kafka.producer.mode", "KAFKA_PRODUCER_MODE")
+	viper.BindEnv("broker.type", "BROKER_TYPE")
+	viper.BindEnv("payment.gateway_url", "PAYMENT_GATEWAY_URL")
+	viper.BindEnv("payment.team_slug", "PAYMENT_TEAM_SLUG")
 	viper.BindEnv("payment.password", "PAYMENT_PASSWORD")
 	viper.BindEnv("external.hackload_base_url", "HACKLOAD_BASE_URL")
 	viper.BindEnv("external_service.hackload.base_url", "HACKLOAD_BASE_URL")
 	viper.BindEnv("external_service.hackload.api_version", "HACKLOAD_API_VERSION")
 	viper.BindEnv("app.url", "APP_URL")
+	viper.BindEnv("app.trusted_proxies", "APP_TRUSTED_PROXIES")
+	viper.BindEnv("auth.jwt_secret", "AUTH_JWT_SECRET")
+	viper.BindEnv("auth.access_token_ttl", "AUTH_ACCESS_TOKEN_TTL")
+	viper.BindEnv("auth.refresh_token_ttl", "AUTH_REFRESH_TOKEN_TTL")
+	viper.BindEnv("user_cache.capacity", "USER_CACHE_CAPACITY")
+	viper.BindEnv("user_cache.ttl", "USER_CACHE_TTL")
+	viper.BindEnv("rate_limit.enabled", "RATE_LIMIT_ENABLED")
+	viper.BindEnv("timeouts.default", "REQUEST_TIMEOUT")
+	viper.BindEnv("tracing.enabled", "TRACING_ENABLED")
+	viper.BindEnv("tracing.endpoint", "TRACING_ENDPOINT")
+	viper.BindEnv("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
 
 	// Пытаемся прочитать конфигурационный файл (опционально)
 	if err := viper.ReadInConfig(); err != nil {
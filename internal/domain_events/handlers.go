@@ -1,56 +1,127 @@
 package domain_events
 
 import (
+	"biletter-service/internal/eventschema"
 	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
 	"biletter-service/internal/repository"
 	"biletter-service/pkg/broker"
+	"biletter-service/pkg/cache"
+	"biletter-service/pkg/requestid"
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"go.uber.org/zap"
 )
 
 // Handlers содержит обработчики для различных типов доменных событий
 type Handlers struct {
-	repos  *repository.Repository
-	logger *zap.Logger
+	repos         *repository.Repository
+	cacheClient   cache.Cache
+	consumerGroup string
+	logger        *zap.Logger
 }
 
 // NewHandlers создает новый Handlers
-func NewHandlers(repos *repository.Repository, logger *zap.Logger) *Handlers {
+func NewHandlers(repos *repository.Repository, cacheClient cache.Cache, consumerGroup string, logger *zap.Logger) *Handlers {
 	return &Handlers{
-		repos:  repos,
-		logger: logger,
+		repos:         repos,
+		cacheClient:   cacheClient,
+		consumerGroup: consumerGroup,
+		logger:        logger,
 	}
 }
 
-// GetMainHandler возвращает основной обработчик событий
+// GetMainHandler возвращает основной обработчик событий, обновляющий все проекции.
+// Kafka может доставить событие повторно, поэтому обработчик выполняется в транзакции
+// вместе с отметкой в processed_events: повторная доставка того же event.ID этой
+// группой consumer'ов пропускается, а при ошибке отметка откатывается вместе с изменениями.
+// Изменения в Redis не транзакционны и применяются только после фиксации транзакции,
+// чтобы повтор после отката не увеличил счетчики дважды.
 func (h *Handlers) GetMainHandler() broker.EventHandler {
+	return h.handler(projections{history: true, readModel: true, notifications: true})
+}
+
+// GetProjectionHandler возвращает обработчик, обновляющий только одну проекцию.
+// Используется для перестроения проекции повторным проигрыванием событий:
+// уведомления пользователям при этом не ставятся.
+func (h *Handlers) GetProjectionHandler(projection Projection) (broker.EventHandler, error) {
+	switch projection {
+	case ProjectionHistory:
+		return h.handler(projections{history: true}), nil
+	case ProjectionReadModel:
+		return h.handler(projections{readModel: true}), nil
+	default:
+		return nil, fmt.Errorf("unknown projection %q", projection)
+	}
+}
+
+func (h *Handlers) handler(enabled projections) broker.EventHandler {
 	return broker.EventHandlerFunc(func(ctx context.Context, event *models.DomainEvent) error {
 		h.logger.Info("Processing domain event",
 			zap.String("event_id", event.ID),
 			zap.String("event_type", string(event.Type)),
-			zap.String("aggregate_id", event.AggregateID))
+			zap.String("aggregate_id", event.AggregateID),
+			zap.String("request_id", requestid.FromContext(ctx)))
+
+		effects := &sideEffects{projections: enabled}
+		err := h.repos.TxManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
+			firstDelivery, err := txRepo.ProcessedEvent.MarkProcessed(ctx, event.ID, h.consumerGroup, string(event.Type))
+			if err != nil {
+				return err
+			}
+			if !firstDelivery {
+				h.logger.Info("Skipping already processed event",
+					zap.String("event_id", event.ID),
+					zap.String("consumer_group", h.consumerGroup))
+				return nil
+			}
+
+			if enabled.history {
+				if err := h.recordBookingEvent(ctx, txRepo, event); err != nil {
+					return err
+				}
+			}
 
-		switch event.Type {
-		case models.BookingCreatedEvent:
-			return h.handleBookingCreated(ctx, event)
-		case models.BookingCancelledEvent:
-			return h.handleBookingCancelled(ctx, event)
-		case models.SeatSelectedEvent:
-			return h.handleSeatSelected(ctx, event)
-		case models.SeatReleasedEvent:
-			return h.handleSeatReleased(ctx, event)
-		default:
-			h.logger.Warn("Unknown event type", zap.String("event_type", string(event.Type)))
-			return nil // Игнорируем неизвестные события
+			if !enabled.readModel && !enabled.notifications {
+				return nil
+			}
+			return h.dispatch(ctx, txRepo, event, effects)
+		})
+		if err != nil {
+			return err
 		}
+
+		if enabled.readModel {
+			h.applySideEffects(ctx, event, effects)
+		}
+		return nil
 	})
 }
 
-// handleBookingCreated обрабатывает событие создания брони
-func (h *Handlers) handleBookingCreated(ctx context.Context, event *models.DomainEvent) error {
+func (h *Handlers) dispatch(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent, effects *sideEffects) error {
+	switch event.Type {
+	case models.BookingCreatedEvent:
+		return h.handleBookingCreated(ctx, txRepo, event, effects)
+	case models.BookingCancelledEvent:
+		return h.handleBookingCancelled(ctx, txRepo, event, effects)
+	case models.SeatSelectedEvent:
+		return h.handleSeatSelected(ctx, txRepo, event, effects)
+	case models.SeatReleasedEvent:
+		return h.handleSeatReleased(ctx, txRepo, event, effects)
+	case models.PaymentUpdatedEvent:
+		return h.handlePaymentUpdated(ctx, txRepo, event, effects)
+	default:
+		h.logger.Warn("Unknown event type", zap.String("event_type", string(event.Type)))
+		return nil // Игнорируем неизвестные события
+	}
+}
+
+// handleBookingCreated обрабатывает событие создания брони:
+// ставит уведомление пользователю и обновляет счетчик броней мероприятия
+func (h *Handlers) handleBookingCreated(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent, effects *sideEffects) error {
 	var data models.BookingCreatedData
 	if err := h.unmarshalEventData(event, &data); err != nil {
 		return fmt.Errorf("failed to unmarshal BookingCreatedData: %w", err)
@@ -61,20 +132,17 @@ func (h *Handlers) handleBookingCreated(ctx context.Context, event *models.Domai
 		zap.Int64("event_id", data.EventID),
 		zap.Int("user_id", data.UserID))
 
-	// Здесь можно добавить логику:
-	// - Отправка email уведомлений
-	// - Обновление статистики
-	// - Логирование аналитики
-	// - Обновление кеша
-
-	// Пример: очистка кеша событий при создании брони
-	// TODO: Implement cache invalidation logic
+	if err := h.enqueueNotification(ctx, txRepo, effects, models.NotificationBookingCreated, data.UserID, data.BookingID, data); err != nil {
+		return err
+	}
 
+	effects.incrementStat(data.EventID, readmodel.StatBookingsCreated)
 	return nil
 }
 
-// handleBookingCancelled обрабатывает событие отмены брони
-func (h *Handlers) handleBookingCancelled(ctx context.Context, event *models.DomainEvent) error {
+// handleBookingCancelled обрабатывает событие отмены брони:
+// ставит уведомление пользователю и обновляет счетчик отмен мероприятия
+func (h *Handlers) handleBookingCancelled(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent, effects *sideEffects) error {
 	var data models.BookingCancelledData
 	if err := h.unmarshalEventData(event, &data); err != nil {
 		return fmt.Errorf("failed to unmarshal BookingCancelledData: %w", err)
@@ -85,17 +153,27 @@ func (h *Handlers) handleBookingCancelled(ctx context.Context, event *models.Dom
 		zap.Int("user_id", data.UserID),
 		zap.String("reason", data.Reason))
 
-	// Здесь можно добавить логику:
-	// - Отправка уведомлений о отмене
-	// - Возврат средств (если было оплачено)
-	// - Обновление статистики
-	// - Освобождение ресурсов
+	if err := h.enqueueNotification(ctx, txRepo, effects, models.NotificationBookingCancelled, data.UserID, data.BookingID, data); err != nil {
+		return err
+	}
 
+	// В событии нет мероприятия - берем его из брони
+	booking, err := txRepo.Booking.GetByID(ctx, data.BookingID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking %d: %w", data.BookingID, err)
+	}
+	if booking == nil {
+		h.logger.Warn("Booking of cancelled event not found, skipping counters", zap.Int64("booking_id", data.BookingID))
+		return nil
+	}
+
+	effects.incrementStat(booking.EventID, readmodel.StatBookingsCancelled)
 	return nil
 }
 
-// handleSeatSelected обрабатывает событие выбора места
-func (h *Handlers) handleSeatSelected(ctx context.Context, event *models.DomainEvent) error {
+// handleSeatSelected обрабатывает событие выбора места:
+// сбрасывает кеш мест мероприятия и обновляет счетчик выбранных мест
+func (h *Handlers) handleSeatSelected(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent, effects *sideEffects) error {
 	var data models.SeatSelectedData
 	if err := h.unmarshalEventData(event, &data); err != nil {
 		return fmt.Errorf("failed to unmarshal SeatSelectedData: %w", err)
@@ -106,17 +184,12 @@ func (h *Handlers) handleSeatSelected(ctx context.Context, event *models.DomainE
 		zap.Int64("seat_id", data.SeatID),
 		zap.Int("user_id", data.UserID))
 
-	// Здесь можно добавить логику:
-	// - Обновление кеша доступных мест
-	// - Отправка уведомлений
-	// - Обновление статистики по местам
-	// - Временная блокировка места с таймаутом
-
-	return nil
+	return h.seatChanged(ctx, txRepo, data.SeatID, readmodel.StatSeatsSelected, effects)
 }
 
-// handleSeatReleased обрабатывает событие освобождения места
-func (h *Handlers) handleSeatReleased(ctx context.Context, event *models.DomainEvent) error {
+// handleSeatReleased обрабатывает событие освобождения места:
+// сбрасывает кеш мест мероприятия и обновляет счетчик освобожденных мест
+func (h *Handlers) handleSeatReleased(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent, effects *sideEffects) error {
 	var data models.SeatReleasedData
 	if err := h.unmarshalEventData(event, &data); err != nil {
 		return fmt.Errorf("failed to unmarshal SeatReleasedData: %w", err)
@@ -127,24 +200,99 @@ func (h *Handlers) handleSeatReleased(ctx context.Context, event *models.DomainE
 		zap.Int64("seat_id", data.SeatID),
 		zap.Int("user_id", data.UserID))
 
-	// Здесь можно добавить логику:
-	// - Обновление кеша доступных мест
-	// - Уведомление ожидающих пользователей
-	// - Обновление статистики
-	// - Очистка временных блокировок
+	return h.seatChanged(ctx, txRepo, data.SeatID, readmodel.StatSeatsReleased, effects)
+}
+
+// handlePaymentUpdated обрабатывает событие изменения статуса оплаты.
+// Кроме записи в журнал брони побочных эффектов нет.
+func (h *Handlers) handlePaymentUpdated(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent, effects *sideEffects) error {
+	var data models.PaymentUpdatedData
+	if err := h.unmarshalEventData(event, &data); err != nil {
+		return fmt.Errorf("failed to unmarshal PaymentUpdatedData: %w", err)
+	}
+
+	h.logger.Info("Processing payment updated event",
+		zap.Int64("booking_id", data.BookingID),
+		zap.String("status", string(data.Status)),
+		zap.String("payment_status", data.PaymentStatus))
 
 	return nil
 }
 
-// unmarshalEventData десериализует данные события
-func (h *Handlers) unmarshalEventData(event *models.DomainEvent, target interface{}) error {
-	dataBytes, err := json.Marshal(event.Data)
+// recordBookingEvent добавляет событие в журнал брони (booking_event_log).
+// AggregateID всех событий - ID брони.
+func (h *Handlers) recordBookingEvent(ctx context.Context, txRepo *repository.TransactionRepository, event *models.DomainEvent) error {
+	bookingID, err := strconv.ParseInt(event.AggregateID, 10, 64)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event data: %w", err)
+		h.logger.Warn("Event aggregate is not a booking, skipping history",
+			zap.String("event_id", event.ID),
+			zap.String("aggregate_id", event.AggregateID))
+		return nil
+	}
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return broker.Permanent(fmt.Errorf("failed to marshal event data: %w", err))
+	}
+
+	return txRepo.BookingEvents.Append(ctx, &models.BookingEventLogEntry{
+		BookingID:    bookingID,
+		EventID:      event.ID,
+		EventType:    event.Type,
+		EventVersion: event.Version,
+		Data:         data,
+		OccurredAt:   event.Timestamp,
+	})
+}
+
+func (h *Handlers) seatChanged(ctx context.Context, txRepo *repository.TransactionRepository, seatID int64, stat string, effects *sideEffects) error {
+	seat, err := txRepo.Seat.GetByID(ctx, seatID)
+	if err != nil {
+		return fmt.Errorf("failed to get seat %d: %w", seatID, err)
+	}
+	if seat == nil {
+		h.logger.Warn("Seat not found, skipping read model update", zap.Int64("seat_id", seatID))
+		return nil
+	}
+
+	effects.invalidateSeats(seat.EventID)
+	effects.incrementStat(seat.EventID, stat)
+	return nil
+}
+
+// enqueueNotification создает задание на уведомление в транзакции обработчика
+func (h *Handlers) enqueueNotification(ctx context.Context, txRepo *repository.TransactionRepository, effects *sideEffects, kind models.NotificationKind, userID int, bookingID int64, data any) error {
+	if !effects.projections.notifications {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return broker.Permanent(fmt.Errorf("failed to marshal notification payload: %w", err))
+	}
+
+	job, err := txRepo.Notification.Enqueue(ctx, &models.NotificationJob{
+		Kind:      kind,
+		UserID:    userID,
+		BookingID: bookingID,
+		Payload:   payload,
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := json.Unmarshal(dataBytes, target); err != nil {
-		return fmt.Errorf("failed to unmarshal event data: %w", err)
+	h.logger.Info("Notification job enqueued",
+		zap.Int64("job_id", job.ID),
+		zap.String("kind", string(kind)),
+		zap.Int("user_id", userID))
+	return nil
+}
+
+// unmarshalEventData проверяет данные события по схеме его версии и приводит их к последней версии.
+// Ошибка схемы не исправится повтором, поэтому помечается как неповторяемая.
+func (h *Handlers) unmarshalEventData(event *models.DomainEvent, target interface{}) error {
+	if err := eventschema.Default.Decode(event, target); err != nil {
+		return broker.Permanent(err)
 	}
 
 	return nil
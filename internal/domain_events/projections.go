@@ -0,0 +1,18 @@
+package domain_events
+
+// Projection проекция доменных событий, которую можно перестроить отдельно
+type Projection string
+
+const (
+	// ProjectionHistory журнал событий брони booking_event_log
+	ProjectionHistory Projection = "history"
+	// ProjectionReadModel счетчики аналитики и кеш мест в Redis
+	ProjectionReadModel Projection = "read_model"
+)
+
+// projections набор проекций, обновляемых обработчиком
+type projections struct {
+	history       bool
+	readModel     bool
+	notifications bool // задания notification_jobs; при перестроении не ставятся
+}
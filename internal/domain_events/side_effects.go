@@ -0,0 +1,57 @@
+package domain_events
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type statIncrement struct {
+	eventID int64
+	field   string
+}
+
+// sideEffects накапливает изменения read model в Redis, которые применяются после фиксации транзакции
+type sideEffects struct {
+	projections projections // какие проекции обновляет обработчик
+	seatEvents  []int64
+	stats       []statIncrement
+}
+
+func (e *sideEffects) invalidateSeats(eventID int64) {
+	e.seatEvents = append(e.seatEvents, eventID)
+}
+
+func (e *sideEffects) incrementStat(eventID int64, field string) {
+	e.stats = append(e.stats, statIncrement{eventID: eventID, field: field})
+}
+
+// applySideEffects применяет изменения в Redis. Ошибки только логируются: кеш мест
+// все равно истечет по TTL, а потеря инкремента лучше повторной обработки события,
+// которая увеличила бы остальные счетчики дважды.
+func (h *Handlers) applySideEffects(ctx context.Context, event *models.DomainEvent, effects *sideEffects) {
+	if h.cacheClient == nil {
+		return
+	}
+
+	for _, eventID := range effects.seatEvents {
+		if err := readmodel.InvalidateSeats(ctx, h.cacheClient, eventID); err != nil {
+			h.logger.Error("Failed to invalidate seats cache",
+				zap.String("domain_event_id", event.ID),
+				zap.Int64("event_id", eventID),
+				zap.Error(err))
+		}
+	}
+
+	for _, stat := range effects.stats {
+		if err := readmodel.IncrementStat(ctx, h.cacheClient, stat.eventID, stat.field, 1); err != nil {
+			h.logger.Error("Failed to update analytics counter",
+				zap.String("domain_event_id", event.ID),
+				zap.Int64("event_id", stat.eventID),
+				zap.String("counter", stat.field),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package eventschema
+
+import (
+	"biletter-service/internal/models"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Snapshot зафиксированное описание версии схемы, используется для проверки совместимости
+type Snapshot struct {
+	Type    models.EventType `json:"type"`
+	Version int              `json:"version"`
+	Fields  []Field          `json:"fields"`
+}
+
+// Field описание поля данных события
+type Field struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Required bool   `json:"required"`
+}
+
+// Describe строит описание схемы по JSON тегам и правилам валидации структуры данных.
+// Обязательным считается поле с правилом required в теге validate: только его
+// проверяет Validate, отсутствие остальных полей сообщения не ломает.
+func Describe(schema Schema) Snapshot {
+	t := indirect(reflect.TypeOf(schema.Payload))
+
+	snapshot := Snapshot{Type: schema.Type, Version: schema.Version}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		snapshot.Fields = append(snapshot.Fields, Field{
+			Name:     name,
+			Kind:     indirect(field.Type).Kind().String(),
+			Required: hasRule(field.Tag.Get("validate"), "required"),
+		})
+	}
+
+	sort.Slice(snapshot.Fields, func(i, j int) bool {
+		return snapshot.Fields[i].Name < snapshot.Fields[j].Name
+	})
+	return snapshot
+}
+
+// Snapshots описывает все схемы реестра
+func (r *Registry) Snapshots() []Snapshot {
+	schemas := r.Schemas()
+	snapshots := make([]Snapshot, 0, len(schemas))
+	for _, schema := range schemas {
+		snapshots = append(snapshots, Describe(schema))
+	}
+	return snapshots
+}
+
+// CheckCompatibility сравнивает текущие схемы реестра с зафиксированными ранее и
+// возвращает список нарушений. Опубликованная версия схемы не должна меняться
+// так, чтобы старые сообщения перестали читаться: поля нельзя удалять, менять
+// их тип или делать обязательными, новые обязательные поля требуют новой версии.
+// Каждая версия выше первой должна иметь upcaster из предыдущей.
+func (r *Registry) CheckCompatibility(published []Snapshot) []string {
+	var violations []string
+
+	current := make(map[string]Snapshot)
+	for _, snapshot := range r.Snapshots() {
+		current[snapshotKey(snapshot.Type, snapshot.Version)] = snapshot
+
+		if snapshot.Version > 1 {
+			if _, ok := r.upcaster(snapshot.Type, snapshot.Version-1); !ok {
+				violations = append(violations, fmt.Sprintf("%s v%d: missing upcaster from v%d",
+					snapshot.Type, snapshot.Version, snapshot.Version-1))
+			}
+		}
+	}
+
+	for _, old := range published {
+		now, ok := current[snapshotKey(old.Type, old.Version)]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("%s v%d: published schema was removed", old.Type, old.Version))
+			continue
+		}
+		violations = append(violations, compareSnapshots(old, now)...)
+	}
+
+	return violations
+}
+
+func compareSnapshots(old, now Snapshot) []string {
+	var violations []string
+	prefix := fmt.Sprintf("%s v%d", old.Type, old.Version)
+
+	nowFields := make(map[string]Field, len(now.Fields))
+	for _, field := range now.Fields {
+		nowFields[field.Name] = field
+	}
+
+	oldFields := make(map[string]Field, len(old.Fields))
+	for _, field := range old.Fields {
+		oldFields[field.Name] = field
+
+		current, ok := nowFields[field.Name]
+		switch {
+		case !ok:
+			violations = append(violations, fmt.Sprintf("%s: field %q was removed", prefix, field.Name))
+		case current.Kind != field.Kind:
+			violations = append(violations, fmt.Sprintf("%s: field %q changed kind %s -> %s", prefix, field.Name, field.Kind, current.Kind))
+		case current.Required && !field.Required:
+			violations = append(violations, fmt.Sprintf("%s: field %q became required", prefix, field.Name))
+		}
+	}
+
+	for _, field := range now.Fields {
+		if _, ok := oldFields[field.Name]; !ok && field.Required {
+			violations = append(violations, fmt.Sprintf("%s: new field %q is required", prefix, field.Name))
+		}
+	}
+
+	return violations
+}
+
+// hasRule проверяет наличие правила в теге validate; required_if и подобные
+// условные правила обязательным поле не делают
+func hasRule(tag, rule string) bool {
+	for _, item := range strings.Split(tag, ",") {
+		name, _, _ := strings.Cut(item, "=")
+		if name == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func snapshotKey(eventType models.EventType, version int) string {
+	return fmt.Sprintf("%s@%d", eventType, version)
+}
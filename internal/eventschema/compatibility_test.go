@@ -0,0 +1,51 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "record current schemas as published")
+
+var publishedSchemasPath = filepath.Join("testdata", "published_schemas.json")
+
+// TestSchemasAreBackwardCompatible падает, если изменение схем ломает чтение уже
+// опубликованных событий. Новые схемы фиксируются через go test -update.
+func TestSchemasAreBackwardCompatible(t *testing.T) {
+	if *update {
+		data, err := json.MarshalIndent(Default.Snapshots(), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(publishedSchemasPath, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(publishedSchemasPath)
+	if err != nil {
+		t.Fatalf("failed to read published schemas: %v", err)
+	}
+
+	var published []Snapshot
+	if err := json.Unmarshal(data, &published); err != nil {
+		t.Fatalf("failed to parse published schemas: %v", err)
+	}
+
+	for _, violation := range Default.CheckCompatibility(published) {
+		t.Error(violation)
+	}
+
+	recorded := make(map[string]bool, len(published))
+	for _, snapshot := range published {
+		recorded[snapshotKey(snapshot.Type, snapshot.Version)] = true
+	}
+	for _, snapshot := range Default.Snapshots() {
+		if !recorded[snapshotKey(snapshot.Type, snapshot.Version)] {
+			t.Errorf("%s v%d is not recorded, run go test ./internal/eventschema -update", snapshot.Type, snapshot.Version)
+		}
+	}
+}
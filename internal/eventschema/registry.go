@@ -0,0 +1,209 @@
+package eventschema
+
+import (
+	"biletter-service/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrUnknownSchema событие с типом или версией, для которых нет схемы
+var ErrUnknownSchema = errors.New("unknown event schema")
+
+// Schema описывает версию полезной нагрузки события конкретного типа
+type Schema struct {
+	Type    models.EventType
+	Version int
+	// Payload пустой экземпляр структуры данных события, задает поля и правила валидации
+	Payload any
+}
+
+// Upcaster преобразует данные события из версии N в версию N+1
+type Upcaster func(data map[string]any) (map[string]any, error)
+
+// Registry хранит схемы и upcaster'ы по типам событий
+type Registry struct {
+	mu        sync.RWMutex
+	schemas   map[models.EventType]map[int]Schema
+	upcasters map[models.EventType]map[int]Upcaster
+	validate  *validator.Validate
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas:   make(map[models.EventType]map[int]Schema),
+		upcasters: make(map[models.EventType]map[int]Upcaster),
+		validate:  validator.New(),
+	}
+}
+
+// Register добавляет схему; Payload должен быть структурой или указателем на структуру
+func (r *Registry) Register(schema Schema) {
+	if schema.Version < 1 {
+		panic(fmt.Sprintf("eventschema: invalid version %d for %s", schema.Version, schema.Type))
+	}
+	if t := reflect.TypeOf(schema.Payload); t == nil || indirect(t).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("eventschema: payload of %s v%d must be a struct", schema.Type, schema.Version))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.schemas[schema.Type] == nil {
+		r.schemas[schema.Type] = make(map[int]Schema)
+	}
+	r.schemas[schema.Type][schema.Version] = schema
+}
+
+// RegisterUpcaster регистрирует преобразование данных из версии fromVersion в fromVersion+1
+func (r *Registry) RegisterUpcaster(eventType models.EventType, fromVersion int, upcaster Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.upcasters[eventType] == nil {
+		r.upcasters[eventType] = make(map[int]Upcaster)
+	}
+	r.upcasters[eventType][fromVersion] = upcaster
+}
+
+// Latest возвращает последнюю версию схемы для типа события
+func (r *Registry) Latest(eventType models.EventType) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := Schema{}
+	for version, schema := range r.schemas[eventType] {
+		if version > latest.Version {
+			latest = schema
+		}
+	}
+	return latest, latest.Version > 0
+}
+
+// Schemas возвращает все зарегистрированные схемы, упорядоченные по типу и версии
+func (r *Registry) Schemas() []Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Schema
+	for _, versions := range r.schemas {
+		for _, schema := range versions {
+			result = append(result, schema)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+// NewEvent создает доменное событие последней версии схемы и проверяет его данные
+func (r *Registry) NewEvent(eventType models.EventType, aggregateID string, data any) (*models.DomainEvent, error) {
+	schema, ok := r.Latest(eventType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, eventType)
+	}
+
+	event := models.NewDomainEvent(eventType, aggregateID, data)
+	event.Version = schema.Version
+
+	if err := r.Validate(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Validate проверяет данные события по схеме его версии
+func (r *Registry) Validate(event *models.DomainEvent) error {
+	schema, ok := r.schema(event.Type, event.Version)
+	if !ok {
+		return fmt.Errorf("%w: %s v%d", ErrUnknownSchema, event.Type, event.Version)
+	}
+
+	payload := reflect.New(indirect(reflect.TypeOf(schema.Payload))).Interface()
+	if err := remarshal(event.Data, payload); err != nil {
+		return fmt.Errorf("invalid %s v%d payload: %w", event.Type, event.Version, err)
+	}
+
+	if err := r.validate.Struct(payload); err != nil {
+		return fmt.Errorf("invalid %s v%d payload: %w", event.Type, event.Version, err)
+	}
+	return nil
+}
+
+// Decode приводит данные события к последней версии схемы и десериализует их в target
+func (r *Registry) Decode(event *models.DomainEvent, target any) error {
+	if err := r.Validate(event); err != nil {
+		return err
+	}
+
+	latest, _ := r.Latest(event.Type)
+
+	var data map[string]any
+	if err := remarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+	}
+
+	for version := event.Version; version < latest.Version; version++ {
+		upcaster, ok := r.upcaster(event.Type, version)
+		if !ok {
+			return fmt.Errorf("no upcaster for %s v%d -> v%d", event.Type, version, version+1)
+		}
+
+		upcasted, err := upcaster(data)
+		if err != nil {
+			return fmt.Errorf("failed to upcast %s v%d -> v%d: %w", event.Type, version, version+1, err)
+		}
+		data = upcasted
+	}
+
+	if err := remarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+	}
+
+	if err := r.validate.Struct(target); err != nil {
+		return fmt.Errorf("invalid %s v%d payload after upcast: %w", event.Type, latest.Version, err)
+	}
+	return nil
+}
+
+func (r *Registry) schema(eventType models.EventType, version int) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.schemas[eventType][version]
+	return schema, ok
+}
+
+func (r *Registry) upcaster(eventType models.EventType, fromVersion int) (Upcaster, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	upcaster, ok := r.upcasters[eventType][fromVersion]
+	return upcaster, ok
+}
+
+// remarshal переводит произвольное значение (например, map после json.Unmarshal) в target
+func remarshal(value any, target any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,189 @@
+package eventschema
+
+import (
+	"biletter-service/internal/models"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testEvent models.EventType = "test.booking_paid"
+
+// testPaidV1 первая версия: сумма в тенге дробным числом
+type testPaidV1 struct {
+	BookingID int64   `json:"booking_id" validate:"required"`
+	Amount    float64 `json:"amount" validate:"gte=0"`
+}
+
+// testPaidV2 вторая версия: сумма в тыйынах и обязательная валюта
+type testPaidV2 struct {
+	BookingID   int64  `json:"booking_id" validate:"required"`
+	TotalAmount int64  `json:"total_amount" validate:"gte=0"`
+	Currency    string `json:"currency" validate:"required"`
+	Comment     string `json:"comment,omitempty" validate:"required_if=Currency USD"`
+}
+
+func upcastPaidV1(data map[string]any) (map[string]any, error) {
+	amount, ok := data["amount"].(float64)
+	if !ok {
+		return nil, errors.New("amount is not a number")
+	}
+
+	return map[string]any{
+		"booking_id":   data["booking_id"],
+		"total_amount": int64(amount * 100),
+		"currency":     "KZT",
+	}, nil
+}
+
+func newTestRegistry(withUpcaster bool) *Registry {
+	registry := NewRegistry()
+	registry.Register(Schema{Type: testEvent, Version: 1, Payload: testPaidV1{}})
+	registry.Register(Schema{Type: testEvent, Version: 2, Payload: testPaidV2{}})
+	if withUpcaster {
+		registry.RegisterUpcaster(testEvent, 1, upcastPaidV1)
+	}
+	return registry
+}
+
+func testDomainEvent(eventType models.EventType, version int, data any) *models.DomainEvent {
+	event := models.NewDomainEvent(eventType, "1", data)
+	event.Version = version
+	return event
+}
+
+func TestRegistryDecode(t *testing.T) {
+	tests := []struct {
+		name         string
+		withUpcaster bool
+		event        *models.DomainEvent
+		want         testPaidV2
+		wantErr      error
+		wantErrText  string
+	}{
+		{
+			name:         "latest version",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 2, map[string]any{"booking_id": 7, "total_amount": 150050, "currency": "KZT"}),
+			want:         testPaidV2{BookingID: 7, TotalAmount: 150050, Currency: "KZT"},
+		},
+		{
+			name:         "old version through upcaster",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 1, map[string]any{"booking_id": 7, "amount": 1500.5}),
+			want:         testPaidV2{BookingID: 7, TotalAmount: 150050, Currency: "KZT"},
+		},
+		{
+			name:         "old version without upcaster",
+			withUpcaster: false,
+			event:        testDomainEvent(testEvent, 1, map[string]any{"booking_id": 7, "amount": 1500.5}),
+			wantErrText:  "no upcaster for test.booking_paid v1 -> v2",
+		},
+		{
+			name:         "upcaster error",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 1, map[string]any{"booking_id": 7}),
+			wantErrText:  "failed to upcast test.booking_paid v1 -> v2",
+		},
+		{
+			name:         "missing required field in old version",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 1, map[string]any{"amount": 10}),
+			wantErrText:  "invalid test.booking_paid v1 payload",
+		},
+		{
+			name:         "missing required field in latest version",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 2, map[string]any{"booking_id": 7, "total_amount": 100}),
+			wantErrText:  "invalid test.booking_paid v2 payload",
+		},
+		{
+			name:         "wrong field type",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 2, map[string]any{"booking_id": "seven", "currency": "KZT"}),
+			wantErrText:  "invalid test.booking_paid v2 payload",
+		},
+		{
+			name:         "unknown type",
+			withUpcaster: true,
+			event:        testDomainEvent("test.unknown", 1, map[string]any{"booking_id": 7}),
+			wantErr:      ErrUnknownSchema,
+		},
+		{
+			name:         "unknown version",
+			withUpcaster: true,
+			event:        testDomainEvent(testEvent, 3, map[string]any{"booking_id": 7}),
+			wantErr:      ErrUnknownSchema,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testPaidV2
+			err := newTestRegistry(tt.withUpcaster).Decode(tt.event, &got)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantErrText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("Decode() error = %v, want %q", err, tt.wantErrText)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if got != tt.want {
+					t.Fatalf("Decode() = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryNewEvent(t *testing.T) {
+	registry := newTestRegistry(true)
+
+	event, err := registry.NewEvent(testEvent, "7", testPaidV2{BookingID: 7, Currency: "KZT"})
+	if err != nil {
+		t.Fatalf("NewEvent() error = %v", err)
+	}
+	if event.Version != 2 {
+		t.Fatalf("NewEvent() version = %d, want 2", event.Version)
+	}
+
+	if _, err := registry.NewEvent(testEvent, "7", testPaidV2{BookingID: 7}); err == nil {
+		t.Fatal("NewEvent() accepted payload without required currency")
+	}
+	if _, err := registry.NewEvent("test.unknown", "7", testPaidV2{}); !errors.Is(err, ErrUnknownSchema) {
+		t.Fatalf("NewEvent() error = %v, want %v", err, ErrUnknownSchema)
+	}
+}
+
+func TestDescribeRequiredFollowsValidateTags(t *testing.T) {
+	snapshot := Describe(Schema{Type: testEvent, Version: 2, Payload: testPaidV2{}})
+
+	want := []Field{
+		{Name: "booking_id", Kind: "int64", Required: true},
+		{Name: "comment", Kind: "string", Required: false},
+		{Name: "currency", Kind: "string", Required: true},
+		{Name: "total_amount", Kind: "int64", Required: false},
+	}
+	if !reflect.DeepEqual(snapshot.Fields, want) {
+		t.Fatalf("Describe() fields = %+v, want %+v", snapshot.Fields, want)
+	}
+}
+
+func TestCheckCompatibilityRequiresUpcaster(t *testing.T) {
+	violations := newTestRegistry(false).CheckCompatibility(nil)
+	if len(violations) != 1 || !strings.Contains(violations[0], "missing upcaster from v1") {
+		t.Fatalf("CheckCompatibility() = %v, want missing upcaster violation", violations)
+	}
+
+	if violations := newTestRegistry(true).CheckCompatibility(nil); len(violations) != 0 {
+		t.Fatalf("CheckCompatibility() = %v, want none", violations)
+	}
+}
@@ -0,0 +1,20 @@
+package eventschema
+
+import "biletter-service/internal/models"
+
+// Default реестр схем доменных событий сервиса.
+// При несовместимом изменении данных события добавьте новую версию схемы
+// и upcaster из предыдущей версии, не меняя уже зарегистрированные.
+var Default = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	registry := NewRegistry()
+
+	registry.Register(Schema{Type: models.BookingCreatedEvent, Version: 1, Payload: models.BookingCreatedData{}})
+	registry.Register(Schema{Type: models.BookingCancelledEvent, Version: 1, Payload: models.BookingCancelledData{}})
+	registry.Register(Schema{Type: models.SeatSelectedEvent, Version: 1, Payload: models.SeatSelectedData{}})
+	registry.Register(Schema{Type: models.SeatReleasedEvent, Version: 1, Payload: models.SeatReleasedData{}})
+	registry.Register(Schema{Type: models.PaymentUpdatedEvent, Version: 1, Payload: models.PaymentUpdatedData{}})
+
+	return registry
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"biletter-service/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetBookingHistory возвращает историю брони, восстановленную из журнала событий
+func (h *Handlers) GetBookingHistory(c *gin.Context) {
+	bookingIDStr := c.Param("id")
+	bookingID, err := strconv.ParseInt(bookingIDStr, 10, 64)
+	if err != nil {
+		h.log(c).Error("Invalid booking ID parameter", zap.String("id", bookingIDStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID parameter"})
+		return
+	}
+
+	history, err := h.services.BookingHistory.GetHistory(c.Request.Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, services.ErrBookingHistoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		}
+		h.log(c).Error("Failed to get booking history", zap.Int64("booking_id", bookingID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get booking history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"biletter-service/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func (h *Handlers) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.services.Auth.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		h.log(c).Info("Login failed", zap.String("email", req.Email), zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handlers) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.services.Auth.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		h.log(c).Info("Token refresh failed", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout отзывает текущий access токен и, если передан, refresh токен
+func (h *Handlers) Logout(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bearer token required"})
+		return
+	}
+
+	if err := h.services.Auth.Revoke(c.Request.Context(), strings.TrimPrefix(authHeader, "Bearer ")); err != nil {
+		h.log(c).Error("Failed to revoke access token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err == nil {
+		if err := h.services.Auth.Revoke(c.Request.Context(), req.RefreshToken); err != nil {
+			h.log(c).Info("Failed to revoke refresh token", zap.Error(err))
+		}
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
@@ -28,9 +28,9 @@ func (h *Handlers) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.services.Booking.CreateBooking(&req, currentUser.UserID)
+	booking, err := h.services.Booking.CreateBooking(c.Request.Context(), &req, currentUser.UserID)
 	if err != nil {
-		h.logger.Error("Failed to create booking", zap.Error(err))
+		h.log(c).Error("Failed to create booking", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -45,9 +45,9 @@ func (h *Handlers) ListBookings(c *gin.Context) {
 		return
 	}
 
-	bookings, err := h.services.Booking.GetBookingsByUser(currentUser.UserID)
+	bookings, err := h.services.Booking.GetBookingsByUser(c.Request.Context(), currentUser.UserID)
 	if err != nil {
-		h.logger.Error("Failed to get bookings", zap.Error(err))
+		h.log(c).Error("Failed to get bookings", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookings"})
 		return
 	}
@@ -68,9 +68,9 @@ func (h *Handlers) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	err := h.services.Booking.CancelBooking(&req, currentUser.UserID)
+	err := h.services.Booking.CancelBooking(c.Request.Context(), &req, currentUser.UserID)
 	if err != nil {
-		h.logger.Error("Failed to cancel booking", zap.Error(err))
+		h.log(c).Error("Failed to cancel booking", zap.Error(err))
 		if isUnauthorizedError(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
@@ -95,9 +95,9 @@ func (h *Handlers) InitiatePayment(c *gin.Context) {
 		return
 	}
 
-	paymentURL, err := h.services.Payment.InitiatePayment(&req, currentUser.UserID)
+	paymentURL, err := h.services.Payment.InitiatePayment(c.Request.Context(), &req, currentUser.UserID)
 	if err != nil {
-		h.logger.Error("Failed to initiate payment", zap.Error(err))
+		h.log(c).Error("Failed to initiate payment", zap.Error(err))
 		if isUnauthorizedError(err) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
@@ -42,9 +42,9 @@ func (h *Handlers) ListEvents(c *gin.Context) {
 		queryPtr = &query
 	}
 
-	events, err := h.services.Event.FindEvents(queryPtr, date, page, pageSize)
+	events, err := h.services.Event.FindEvents(c.Request.Context(), queryPtr, date, page, pageSize)
 	if err != nil {
-		h.logger.Error("Failed to get events", zap.Error(err))
+		h.log(c).Error("Failed to get events", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get events"})
 		return
 	}
@@ -53,6 +53,6 @@ func (h *Handlers) ListEvents(c *gin.Context) {
 }
 
 func (h *Handlers) ClearEventsCache(c *gin.Context) {
-	h.services.Event.ClearCache()
+	h.services.Event.ClearCache(c.Request.Context())
 	c.JSON(http.StatusOK, gin.H{"message": "Events cache cleared successfully"})
 }
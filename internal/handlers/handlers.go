@@ -3,45 +3,77 @@ package handlers
 import (
 	"biletter-service/internal/middleware"
 	"biletter-service/internal/services"
+	"biletter-service/pkg/health"
+	"biletter-service/pkg/logger"
+	"biletter-service/pkg/metrics"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type Handlers struct {
-	services *services.Services
-	logger   *zap.Logger
+	services    *services.Services
+	middlewares Middlewares
+	health      *health.Checker
+	logger      *zap.Logger
 }
 
-func New(services *services.Services, logger *zap.Logger) *Handlers {
+// Middlewares middleware с внешними зависимостями, подключаемые к отдельным маршрутам
+type Middlewares struct {
+	RateLimiter *middleware.RateLimiter
+	Idempotency *middleware.Idempotency
+	Timeouts    *middleware.Timeouts
+	Admin       gin.HandlerFunc // проверка прав администратора, подключается после Auth
+}
+
+func New(services *services.Services, middlewares Middlewares, healthChecker *health.Checker, logger *zap.Logger) *Handlers {
 	return &Handlers{
-		services: services,
-		logger:   logger,
+		services:    services,
+		middlewares: middlewares,
+		health:      healthChecker,
+		logger:      logger,
 	}
 }
 
+// log возвращает логгер запроса с request_id, если его добавил middleware.RequestLogger
+func (h *Handlers) log(c *gin.Context) *zap.Logger {
+	return logger.FromContext(c.Request.Context(), h.logger)
+}
+
 func (h *Handlers) RegisterRoutes(router *gin.Engine) {
+	timeout := h.middlewares.Timeouts.For
+
 	api := router.Group("/api")
 	{
 		// Публичные эндпойнты (без аутентификации)
-		events := api.Group("/events")
+		events := api.Group("/events", timeout("events"), h.middlewares.RateLimiter.Limit("events"))
 		{
 			events.GET("", h.ListEvents)
 			events.POST("/cache/clear", h.ClearEventsCache)
 		}
 
+		// Выдача и обновление токенов
+		authRoutes := api.Group("/auth", timeout("auth"))
+		{
+			authRoutes.POST("/login", h.Login)
+			authRoutes.POST("/refresh", h.RefreshToken)
+		}
+
+		// Регистрация пользователя
+		api.POST("/users", timeout("users"), h.RegisterUser)
+
 		// Reset endpoint (публичный для удобства тестирования)
-		api.POST("/reset", h.ResetData)
+		api.POST("/reset", timeout("reset"), h.ResetData)
 
 		// Analytics endpoint (публичный)
-		api.GET("/analytics", h.GetAnalytics)
+		api.GET("/analytics", timeout("analytics"), h.GetAnalytics)
 
 		// Seats endpoint (публичный)
-		api.GET("/seats", h.ListSeats)
+		api.GET("/seats", timeout("seats"), h.ListSeats)
 
 		// Payment endpoints (webhooks and redirects - no auth required)
 		paymentHandler := NewPaymentHandler(h.services.Payment, h.logger)
-		payments := api.Group("/payments")
+		payments := api.Group("/payments", timeout("payments"))
 		{
 			payments.POST("/notifications", paymentHandler.PaymentNotifications)
 			payments.GET("/success", paymentHandler.PaymentSuccess)
@@ -49,24 +81,45 @@ func (h *Handlers) RegisterRoutes(router *gin.Engine) {
 		}
 
 		// Защищенные эндпойнты (требуют аутентификацию)
-		auth := api.Group("", middleware.BasicAuth(h.services.User))
+		auth := api.Group("", middleware.Auth(h.services.Auth, h.services.User))
 		{
-			seats := auth.Group("/seats")
+			auth.POST("/auth/logout", timeout("auth"), h.Logout)
+
+			users := auth.Group("/users/me", timeout("users"))
+			{
+				users.GET("", h.GetCurrentUserProfile)
+				users.PATCH("", h.UpdateCurrentUserProfile)
+				users.PUT("/password", h.ChangePassword)
+				users.DELETE("", h.DeactivateCurrentUser)
+			}
+
+			seats := auth.Group("/seats", timeout("seats"))
 			{
-				seats.PATCH("/select", h.SelectSeat)
+				seats.PATCH("/select", h.middlewares.RateLimiter.Limit("seats_select"), h.SelectSeat)
 				seats.PATCH("/release", h.ReleaseSeat)
 				seats.POST("/fill-big-event", h.FillSeats)
 			}
 
-			bookings := auth.Group("/bookings")
+			bookings := auth.Group("/bookings", timeout("bookings"))
 			{
-				bookings.POST("", h.CreateBooking)
+				bookings.POST("", h.middlewares.Idempotency.Handler(), h.CreateBooking)
 				bookings.GET("", h.ListBookings)
-				bookings.PATCH("/initiatePayment", h.InitiatePayment)
 				bookings.PATCH("/cancel", h.CancelBooking)
 			}
+
+			// Инициация оплаты ждет платежный шлюз (до 30s), поэтому живет в группе payments,
+			// а не под дедлайном bookings
+			auth.PATCH("/bookings/initiatePayment", timeout("payments"), h.middlewares.Idempotency.Handler(), h.InitiatePayment)
+
+			admin := auth.Group("/admin", timeout("admin"), h.middlewares.Admin)
+			{
+				admin.GET("/bookings/:id/history", h.GetBookingHistory)
+			}
 		}
 	}
 
 	router.GET("/health", h.Health)
+	router.GET("/health/live", h.Live)
+	router.GET("/health/ready", h.Ready)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 }
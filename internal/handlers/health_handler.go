@@ -12,3 +12,25 @@ func (h *Handlers) Health(c *gin.Context) {
 		"service": "biletter-service",
 	})
 }
+
+// Live сообщает, что процесс жив и обрабатывает HTTP; зависимости не проверяются,
+// чтобы недоступность базы не приводила к перезапуску контейнера
+func (h *Handlers) Live(c *gin.Context) {
+	h.Health(c)
+}
+
+// Ready проверяет зависимости и отдает 503, пока сервис не готов принимать трафик
+func (h *Handlers) Ready(c *gin.Context) {
+	if h.health == nil {
+		h.Health(c)
+		return
+	}
+
+	report := h.health.Run(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
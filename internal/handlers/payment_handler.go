@@ -3,6 +3,7 @@ package handlers
 import (
 	"biletter-service/internal/models"
 	"biletter-service/internal/services"
+	"biletter-service/pkg/logger"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,11 @@ type PaymentHandler struct {
 	validator      *validator.Validate
 }
 
+// log возвращает логгер запроса с request_id, если его добавил middleware.RequestLogger
+func (h *PaymentHandler) log(c *gin.Context) *zap.Logger {
+	return logger.FromContext(c.Request.Context(), h.logger)
+}
+
 func NewPaymentHandler(paymentService services.PaymentService, logger *zap.Logger) *PaymentHandler {
 	return &PaymentHandler{
 		paymentService: paymentService,
@@ -30,32 +36,32 @@ func (h *PaymentHandler) PaymentNotifications(c *gin.Context) {
 	var payload models.PaymentNotificationPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		h.logger.Error("Failed to bind payment notification payload", zap.Error(err))
+		h.log(c).Error("Failed to bind payment notification payload", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
 
 	if err := h.validator.Struct(&payload); err != nil {
-		h.logger.Error("Payment notification payload validation failed", zap.Error(err))
+		h.log(c).Error("Payment notification payload validation failed", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed"})
 		return
 	}
 
-	h.logger.Info("Payment webhook notification received",
+	h.log(c).Info("Payment webhook notification received",
 		zap.String("paymentId", payload.PaymentID),
 		zap.String("status", payload.Status),
 		zap.Any("data", payload.Data))
 
-	err := h.paymentService.ProcessPaymentNotification(&payload)
+	err := h.paymentService.ProcessPaymentNotification(c.Request.Context(), &payload)
 	if err != nil {
-		h.logger.Error("Error processing payment webhook",
+		h.log(c).Error("Error processing payment webhook",
 			zap.String("paymentId", payload.PaymentID),
 			zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing webhook"})
 		return
 	}
 
-	h.logger.Info("Successfully processed payment webhook",
+	h.log(c).Info("Successfully processed payment webhook",
 		zap.String("paymentId", payload.PaymentID))
 
 	c.String(http.StatusOK, "OK")
@@ -66,23 +72,23 @@ func (h *PaymentHandler) PaymentNotifications(c *gin.Context) {
 func (h *PaymentHandler) PaymentSuccess(c *gin.Context) {
 	orderId := c.Query("orderId")
 	//if orderId == "" {
-	//	h.logger.Error("Missing orderId parameter in payment success redirect")
+	//	h.log(c).Error("Missing orderId parameter in payment success redirect")
 	//	c.JSON(http.StatusBadRequest, gin.H{"error": "Missing orderId parameter"})
 	//	return
 	//}
 	//
-	//h.logger.Info("Payment success redirect received", zap.String("orderId", orderId))
+	//h.log(c).Info("Payment success redirect received", zap.String("orderId", orderId))
 	//
 	//err := h.paymentService.NotifyPaymentSuccess(orderId)
 	//if err != nil {
-	//	h.logger.Error("Error processing payment success",
+	//	h.log(c).Error("Error processing payment success",
 	//		zap.String("orderId", orderId),
 	//		zap.Error(err))
 	//	c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing payment success"})
 	//	return
 	//}
 
-	h.logger.Info("Successfully processed payment success", zap.String("orderId", orderId))
+	h.log(c).Info("Successfully processed payment success", zap.String("orderId", orderId))
 	c.String(http.StatusOK, "Payment successful! Your booking has been confirmed.")
 }
 
@@ -91,22 +97,22 @@ func (h *PaymentHandler) PaymentSuccess(c *gin.Context) {
 func (h *PaymentHandler) PaymentFail(c *gin.Context) {
 	orderId := c.Query("orderId")
 	//if orderId == "" {
-	//	h.logger.Error("Missing orderId parameter in payment failure redirect")
+	//	h.log(c).Error("Missing orderId parameter in payment failure redirect")
 	//	c.JSON(http.StatusBadRequest, gin.H{"error": "Missing orderId parameter"})
 	//	return
 	//}
 	//
-	//h.logger.Info("Payment failure redirect received", zap.String("orderId", orderId))
+	//h.log(c).Info("Payment failure redirect received", zap.String("orderId", orderId))
 	//
 	//err := h.paymentService.NotifyPaymentFailure(orderId)
 	//if err != nil {
-	//	h.logger.Error("Error processing payment failure",
+	//	h.log(c).Error("Error processing payment failure",
 	//		zap.String("orderId", orderId),
 	//		zap.Error(err))
 	//	c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing payment failure"})
 	//	return
 	//}
 
-	h.logger.Info("Successfully processed payment failure", zap.String("orderId", orderId))
+	h.log(c).Info("Successfully processed payment failure", zap.String("orderId", orderId))
 	c.String(http.StatusOK, "Payment failed. Your booking has been cancelled.")
 }
@@ -9,15 +9,15 @@ import (
 
 // ResetData сбрасывает все данные броней и мест
 func (h *Handlers) ResetData(c *gin.Context) {
-	h.logger.Info("Reset data endpoint called")
+	h.log(c).Info("Reset data endpoint called")
 
-	err := h.services.Reset.ResetAllData()
+	err := h.services.Reset.ResetAllData(c.Request.Context())
 	if err != nil {
-		h.logger.Error("Failed to reset data", zap.Error(err))
+		h.log(c).Error("Failed to reset data", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset data"})
 		return
 	}
 
-	h.logger.Info("Data reset completed successfully")
+	h.log(c).Info("Data reset completed successfully")
 	c.JSON(http.StatusOK, gin.H{"message": "All data reset successfully"})
 }
@@ -46,9 +46,9 @@ func (h *Handlers) ListSeats(c *gin.Context) {
 	}
 	row, err := strconv.ParseInt(rowStr, 10, 64)
 
-	seats, err := h.services.Seat.GetSeatsByEvent(eventID, status, row, page, pageSize)
+	seats, err := h.services.Seat.GetSeatsByEvent(c.Request.Context(), eventID, status, row, page, pageSize)
 	if err != nil {
-		h.logger.Error("Failed to get seats", zap.Error(err))
+		h.log(c).Error("Failed to get seats", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get seats"})
 		return
 	}
@@ -77,9 +77,9 @@ func (h *Handlers) SelectSeat(c *gin.Context) {
 		return
 	}
 
-	err := h.services.Booking.SelectSeat(req.BookingID, req.SeatID, currentUser.UserID)
+	err := h.services.Booking.SelectSeat(c.Request.Context(), req.BookingID, req.SeatID, currentUser.UserID)
 	if err != nil {
-		h.logger.Error("Failed to select seat", zap.Error(err))
+		h.log(c).Error("Failed to select seat", zap.Error(err))
 		if strings.Contains(strings.ToLower(err.Error()), "unauthorized") {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
@@ -104,9 +104,9 @@ func (h *Handlers) ReleaseSeat(c *gin.Context) {
 		return
 	}
 
-	err := h.services.Booking.ReleaseSeat(req.SeatID, currentUser.UserID)
+	err := h.services.Booking.ReleaseSeat(c.Request.Context(), req.SeatID, currentUser.UserID)
 	if err != nil {
-		h.logger.Error("Failed to release seat", zap.Error(err))
+		h.log(c).Error("Failed to release seat", zap.Error(err))
 		if strings.Contains(strings.ToLower(err.Error()), "unauthorized") {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
@@ -0,0 +1,396 @@
+//go:build integration
+
+package integration
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/internal/services"
+	"biletter-service/pkg/paymentmock"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestBookingPaymentFlow бронь -> выбор мест -> оплата на странице шлюза -> webhook -> CONFIRMED
+func TestBookingPaymentFlow(t *testing.T) {
+	h := requireEnv(t)
+	if err := h.payments.SetSettings(paymentmock.Settings{Outcome: paymentmock.StatusConfirmed, NotificationRetries: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	eventID, seats := h.createEvent(t, 1, 3)
+	user := h.newUser(t)
+
+	bookingID := user.createBooking(eventID)
+	user.selectSeat(bookingID, seats[0])
+	user.selectSeat(bookingID, seats[1])
+
+	var bookings []models.ListBookingsResponseItem
+	user.mustDo(http.MethodGet, "/api/bookings", nil, http.StatusOK, &bookings)
+	if len(bookings) != 1 || bookings[0].ID != bookingID || len(bookings[0].Seats) != 2 {
+		t.Fatalf("bookings = %+v, want booking %d with 2 seats", bookings, bookingID)
+	}
+
+	resp := user.mustDo(http.MethodPatch, "/api/bookings/initiatePayment", models.InitiatePaymentRequest{BookingID: bookingID}, http.StatusFound, nil)
+	paymentURL := resp.Header.Get("Location")
+	if paymentURL == "" {
+		t.Fatal("initiatePayment returned no payment URL")
+	}
+	if status := h.booking(t, bookingID).Status; status != models.BookingStatusPaymentPending {
+		t.Fatalf("booking status after initiatePayment = %s, want %s", status, models.BookingStatusPaymentPending)
+	}
+
+	// Покупатель платит на странице шлюза и возвращается на successURL сервиса
+	redirect := pay(t, paymentURL, "")
+	if !strings.HasPrefix(redirect, h.cfg.App.URL+"/payments/success") {
+		t.Fatalf("payment page redirected to %q, want success URL", redirect)
+	}
+	success, err := http.Get(redirect)
+	if err != nil {
+		t.Fatalf("success redirect: %v", err)
+	}
+	success.Body.Close()
+	if success.StatusCode != http.StatusOK {
+		t.Fatalf("success redirect status = %d, want %d", success.StatusCode, http.StatusOK)
+	}
+
+	eventually(t, func() error {
+		booking := h.booking(t, bookingID)
+		if booking.Status != models.BookingStatusConfirmed {
+			return fmt.Errorf("booking status is %s", booking.Status)
+		}
+		if booking.PaymentID == nil {
+			return fmt.Errorf("payment ID is not saved")
+		}
+		return nil
+	})
+
+	notifications := h.payments.Notifications()
+	if len(notifications) == 0 || notifications[len(notifications)-1].StatusCode != http.StatusOK {
+		t.Fatalf("webhook deliveries = %+v, want accepted CONFIRMED notification", notifications)
+	}
+	if count := h.bookingSeatCount(t, bookingID); count != 2 {
+		t.Fatalf("confirmed booking has %d seats, want 2", count)
+	}
+
+	// Стоимость брони - сумма цен мест, в шлюз она уходит в тыйынах
+	booking := h.booking(t, bookingID)
+	wantTotal := seatPrice.Mul(decimal.NewFromInt(2))
+	if !booking.TotalAmount.Equal(wantTotal) {
+		t.Fatalf("booking total = %s, want %s", booking.TotalAmount, wantTotal)
+	}
+	amount, ok := h.payments.PaymentAmount(*booking.PaymentID)
+	if wantAmount := wantTotal.Mul(decimal.NewFromInt(100)).IntPart(); !ok || amount != wantAmount {
+		t.Fatalf("gateway payment amount = %d, want %d", amount, wantAmount)
+	}
+
+	// Все события брони проходят через брокер и попадают в журнал
+	eventually(t, func() error {
+		entries, err := h.repos.BookingEvents.GetByBookingID(context.Background(), bookingID)
+		if err != nil {
+			return err
+		}
+		types := make(map[models.EventType]int)
+		for _, entry := range entries {
+			types[entry.EventType]++
+		}
+		if types[models.BookingCreatedEvent] != 1 || types[models.SeatSelectedEvent] != 2 || types[models.PaymentUpdatedEvent] != 2 {
+			return fmt.Errorf("booking history = %v", types)
+		}
+		return nil
+	})
+}
+
+// TestPaymentFailureCancelsBooking отказ в оплате переводит бронь в CANCELLED и освобождает ее места
+func TestPaymentFailureCancelsBooking(t *testing.T) {
+	h := requireEnv(t)
+	if err := h.payments.SetSettings(paymentmock.Settings{Outcome: paymentmock.StatusConfirmed, NotificationRetries: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	eventID, seats := h.createEvent(t, 1, 1)
+	user := h.newUser(t)
+
+	bookingID := user.createBooking(eventID)
+	user.selectSeat(bookingID, seats[0])
+	resp := user.mustDo(http.MethodPatch, "/api/bookings/initiatePayment", models.InitiatePaymentRequest{BookingID: bookingID}, http.StatusFound, nil)
+
+	redirect := pay(t, resp.Header.Get("Location"), paymentmock.StatusFailed)
+	if !strings.HasPrefix(redirect, h.cfg.App.URL+"/payments/fail") {
+		t.Fatalf("payment page redirected to %q, want fail URL", redirect)
+	}
+
+	eventually(t, func() error {
+		if status := h.booking(t, bookingID).Status; status != models.BookingStatusCancelled {
+			return fmt.Errorf("booking status is %s", status)
+		}
+		return nil
+	})
+
+	// Повторная оплата отмененной брони невозможна
+	user.mustDo(http.MethodPatch, "/api/bookings/initiatePayment", models.InitiatePaymentRequest{BookingID: bookingID}, http.StatusConflict, nil)
+
+	if status := h.seatStatuses(t, eventID)[seats[0]]; status != models.SeatStatusFree {
+		t.Fatalf("seat of the cancelled booking is %s, want FREE", status)
+	}
+	if count := h.bookingSeatCount(t, bookingID); count != 0 {
+		t.Fatalf("cancelled booking still has %d seats", count)
+	}
+	if total := h.booking(t, bookingID).TotalAmount; !total.IsZero() {
+		t.Fatalf("cancelled booking total = %s, want 0", total)
+	}
+	h.assertSeatInvariants(t, eventID)
+
+	// Освобожденное место может выбрать другой пользователь
+	other := h.newUser(t)
+	other.selectSeat(other.createBooking(eventID), seats[0])
+}
+
+// TestCancelBookingReleasesSeats отмена брони освобождает места для других пользователей
+func TestCancelBookingReleasesSeats(t *testing.T) {
+	h := requireEnv(t)
+
+	eventID, seats := h.createEvent(t, 1, 3)
+	owner := h.newUser(t)
+	other := h.newUser(t)
+
+	bookingID := owner.createBooking(eventID)
+	owner.selectSeat(bookingID, seats[0])
+	owner.selectSeat(bookingID, seats[1])
+
+	// Занятое место другой пользователь выбрать не может, чужую бронь отменить тоже
+	otherBookingID := other.createBooking(eventID)
+	other.mustDo(http.MethodPatch, "/api/seats/select", models.SelectSeatRequest{BookingID: otherBookingID, SeatID: seats[0]}, 419, nil)
+	other.mustDo(http.MethodPatch, "/api/bookings/cancel", models.CancelBookingRequest{BookingID: bookingID}, http.StatusForbidden, nil)
+
+	owner.mustDo(http.MethodPatch, "/api/bookings/cancel", models.CancelBookingRequest{BookingID: bookingID}, http.StatusOK, nil)
+
+	if status := h.booking(t, bookingID).Status; status != models.BookingStatusCancelled {
+		t.Fatalf("booking status = %s, want %s", status, models.BookingStatusCancelled)
+	}
+	if count := h.bookingSeatCount(t, bookingID); count != 0 {
+		t.Fatalf("cancelled booking still has %d seats", count)
+	}
+	if total := h.booking(t, bookingID).TotalAmount; !total.IsZero() {
+		t.Fatalf("cancelled booking total = %s, want 0", total)
+	}
+	statuses := h.seatStatuses(t, eventID)
+	for _, seatID := range seats {
+		if statuses[seatID] != models.SeatStatusFree {
+			t.Fatalf("seat %d status = %s, want %s", seatID, statuses[seatID], models.SeatStatusFree)
+		}
+	}
+
+	owner.mustDo(http.MethodPatch, "/api/bookings/cancel", models.CancelBookingRequest{BookingID: bookingID}, http.StatusBadRequest, nil)
+	other.selectSeat(otherBookingID, seats[0])
+}
+
+// TestResetRestoresInitialState POST /api/reset удаляет брони и освобождает места
+func TestResetRestoresInitialState(t *testing.T) {
+	h := requireEnv(t)
+
+	eventID, seats := h.createEvent(t, 2, 2)
+	user := h.newUser(t)
+
+	for _, seatID := range seats[:3] {
+		bookingID := user.createBooking(eventID)
+		user.selectSeat(bookingID, seatID)
+	}
+
+	// Счетчики ведет consumer: ждем обработки событий, иначе они досчитаются уже после сброса
+	eventually(t, func() error {
+		var analytics models.AnalyticsResponse
+		user.mustDo(http.MethodGet, fmt.Sprintf("/api/analytics?id=%d", eventID), nil, http.StatusOK, &analytics)
+		if stream := analytics.EventStream; stream == nil || stream.BookingsCreated != 3 || stream.SeatsSelected != 3 {
+			return fmt.Errorf("event stream stats = %+v", analytics.EventStream)
+		}
+		return nil
+	})
+
+	var before models.AnalyticsResponse
+	user.mustDo(http.MethodGet, fmt.Sprintf("/api/analytics?id=%d", eventID), nil, http.StatusOK, &before)
+	if before.ReservedSeats != 3 || before.FreeSeats != 1 || before.BookingsCount != 3 {
+		t.Fatalf("analytics before reset = %+v, want 3 reserved, 1 free and 3 bookings", before)
+	}
+
+	user.mustDo(http.MethodPost, "/api/reset", nil, http.StatusOK, nil)
+
+	var bookings []models.ListBookingsResponseItem
+	user.mustDo(http.MethodGet, "/api/bookings", nil, http.StatusOK, &bookings)
+	if len(bookings) != 0 {
+		t.Fatalf("bookings after reset = %+v, want none", bookings)
+	}
+
+	var after models.AnalyticsResponse
+	user.mustDo(http.MethodGet, fmt.Sprintf("/api/analytics?id=%d", eventID), nil, http.StatusOK, &after)
+	if after.TotalSeats != len(seats) || after.FreeSeats != len(seats) || after.ReservedSeats != 0 || after.BookingsCount != 0 {
+		t.Fatalf("analytics after reset = %+v, want all %d seats free and no bookings", after, len(seats))
+	}
+	if after.EventStream != nil {
+		t.Fatalf("event stream stats after reset = %+v, want none", after.EventStream)
+	}
+
+	// После сброса места снова можно выбрать
+	user.selectSeat(user.createBooking(eventID), seats[0])
+}
+
+// pay проводит оплату на странице шлюза и возвращает адрес редиректа
+func pay(t *testing.T, paymentURL, outcome string) string {
+	t.Helper()
+
+	form := url.Values{}
+	if outcome != "" {
+		form.Set("outcome", outcome)
+	}
+	resp, err := noRedirects.PostForm(paymentURL, form)
+	if err != nil {
+		t.Fatalf("payment page: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("payment page status = %d, want %d", resp.StatusCode, http.StatusSeeOther)
+	}
+	return resp.Header.Get("Location")
+}
+
+// TestConcurrentRegistrationSameEmail одновременная регистрация одного email: один пользователь
+// создан, остальные получают ErrUserAlreadyExists, а не ошибку уникального индекса
+func TestConcurrentRegistrationSameEmail(t *testing.T) {
+	h := requireEnv(t)
+	ctx := context.Background()
+
+	const attempts = 20
+	email := fmt.Sprintf("it-race-%d@example.com", time.Now().UnixNano())
+
+	errs := make([]error, attempts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, errs[i] = h.services.User.Register(ctx, &models.RegisterUserRequest{
+				Email:     email,
+				Password:  "integration-password",
+				FirstName: "Integration",
+				Surname:   "Race",
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, services.ErrUserAlreadyExists):
+			t.Fatalf("unexpected registration error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("%d registrations succeeded, want 1", created)
+	}
+}
+
+// TestLoginWithRegisteredEmailCasing email хранится в нижнем регистре, но пользователь
+// входит тем же написанием, что ввел при регистрации: через Basic Auth и /api/auth/login
+func TestLoginWithRegisteredEmailCasing(t *testing.T) {
+	h := requireEnv(t)
+
+	user := &client{
+		h:        h,
+		t:        t,
+		email:    fmt.Sprintf("It-Case-%d@Example.COM", time.Now().UnixNano()),
+		password: "integration-password",
+	}
+
+	var registered models.User
+	(&client{h: h, t: t}).mustDo(http.MethodPost, "/api/users", models.RegisterUserRequest{
+		Email:     user.email,
+		Password:  user.password,
+		FirstName: "Integration",
+		Surname:   "Case",
+	}, http.StatusCreated, &registered)
+	if registered.Email != strings.ToLower(user.email) {
+		t.Fatalf("registered email = %q, want %q", registered.Email, strings.ToLower(user.email))
+	}
+
+	// Basic Auth с исходным написанием
+	var profile models.User
+	user.mustDo(http.MethodGet, "/api/users/me", nil, http.StatusOK, &profile)
+	if profile.UserID != registered.UserID {
+		t.Fatalf("profile user = %d, want %d", profile.UserID, registered.UserID)
+	}
+
+	// Выдача токенов с исходным написанием
+	var tokens models.TokenResponse
+	(&client{h: h, t: t}).mustDo(http.MethodPost, "/api/auth/login", models.LoginRequest{
+		Email:    user.email,
+		Password: user.password,
+	}, http.StatusOK, &tokens)
+	if tokens.AccessToken == "" {
+		t.Fatal("login returned no access token")
+	}
+
+	// Повторная регистрация в другом регистре - тот же пользователь
+	(&client{h: h, t: t}).mustDo(http.MethodPost, "/api/users", models.RegisterUserRequest{
+		Email:     strings.ToUpper(user.email),
+		Password:  user.password,
+		FirstName: "Integration",
+		Surname:   "Case",
+	}, http.StatusConflict, nil)
+}
+
+// TestConcurrentRefreshRotatesOnce одновременный обмен одного refresh токена:
+// новую пару получает только один запрос, остальные отклоняются
+func TestConcurrentRefreshRotatesOnce(t *testing.T) {
+	h := requireEnv(t)
+	ctx := context.Background()
+	user := h.newUser(t)
+
+	tokens, err := h.services.Auth.Login(ctx, user.email, user.password)
+	if err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+
+	const attempts = 20
+	errs := make([]error, attempts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, errs[i] = h.services.Auth.Refresh(ctx, tokens.RefreshToken)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	refreshed := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			refreshed++
+		case !errors.Is(err, services.ErrTokenRevoked):
+			t.Fatalf("unexpected refresh error: %v", err)
+		}
+	}
+	if refreshed != 1 {
+		t.Fatalf("%d refreshes succeeded, want 1", refreshed)
+	}
+}
@@ -0,0 +1,307 @@
+//go:build integration
+
+package integration
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/internal/services"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestConcurrentSelectSingleWinner все пользователи одновременно выбирают одни и те же места:
+// каждое место достается ровно одной брони
+func TestConcurrentSelectSingleWinner(t *testing.T) {
+	h := requireEnv(t)
+	ctx := context.Background()
+
+	const users = 40
+	eventID, seats := h.createEvent(t, 1, 10)
+
+	type holder struct {
+		userID    int
+		bookingID int64
+	}
+	holders := make([]holder, users)
+	for i := range holders {
+		user := h.newUser(t)
+		created, err := h.services.Booking.CreateBooking(ctx, &models.CreateBookingRequest{EventID: eventID}, user.userID)
+		if err != nil {
+			t.Fatalf("failed to create booking: %v", err)
+		}
+		holders[i] = holder{userID: user.userID, bookingID: created.ID}
+	}
+
+	var mu sync.Mutex
+	winners := make(map[int64][]int64) // место -> брони, которым удался выбор
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, hd := range holders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			for _, i := range rand.Perm(len(seats)) {
+				err := h.services.Booking.SelectSeat(ctx, hd.bookingID, seats[i], hd.userID)
+				if err != nil {
+					checkExpected(t, "select", err, services.ErrSeatNotAvailable)
+					continue
+				}
+				mu.Lock()
+				winners[seats[i]] = append(winners[seats[i]], hd.bookingID)
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	links := h.seatLinks(t, eventID)
+	for _, seatID := range seats {
+		if len(winners[seatID]) != 1 {
+			t.Fatalf("seat %d selected by bookings %v, want exactly one", seatID, winners[seatID])
+		}
+		if got := links[seatID]; len(got) != 1 || got[0] != winners[seatID][0] {
+			t.Fatalf("seat %d is linked to bookings %v, want [%d]", seatID, got, winners[seatID][0])
+		}
+	}
+
+	h.assertSeatInvariants(t, eventID)
+}
+
+// TestConcurrentSeatOperationsKeepInvariants тысячи параллельных выборов, освобождений и отмен:
+// места не раздваиваются, связи не теряются, аналитика сходится с базой
+func TestConcurrentSeatOperationsKeepInvariants(t *testing.T) {
+	h := requireEnv(t)
+	ctx := context.Background()
+
+	const (
+		users            = 16
+		workersPerUser   = 2 // воркеры пользователя делят текущую бронь: выбор гоняется с отменой
+		selectPercent    = 60
+		releasePercent   = 30
+		operationsNormal = 5000
+		operationsShort  = 500
+	)
+	operations := operationsNormal
+	if testing.Short() {
+		operations = operationsShort
+	}
+
+	eventID, seats := h.createEvent(t, 5, 10)
+
+	var bookingsCreated atomic.Int64
+	newBooking := func(userID int) int64 {
+		created, err := h.services.Booking.CreateBooking(ctx, &models.CreateBookingRequest{EventID: eventID}, userID)
+		if err != nil {
+			t.Errorf("failed to create booking: %v", err)
+			return 0
+		}
+		bookingsCreated.Add(1)
+		return created.ID
+	}
+
+	type account struct {
+		userID  int
+		booking atomic.Int64 // текущая бронь; после отмены заменяется новой
+	}
+	accounts := make([]*account, users)
+	for i := range accounts {
+		accounts[i] = &account{userID: h.newUser(t).userID}
+		accounts[i].booking.Store(newBooking(accounts[i].userID))
+	}
+
+	var remaining atomic.Int64
+	remaining.Store(int64(operations))
+	var selected, released, cancelled atomic.Int64
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, acc := range accounts {
+		for range workersPerUser {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+
+				for remaining.Add(-1) >= 0 && !t.Failed() {
+					bookingID := acc.booking.Load()
+					seatID := seats[rand.N(len(seats))]
+
+					switch op := rand.N(100); {
+					case op < selectPercent:
+						if err := h.services.Booking.SelectSeat(ctx, bookingID, seatID, acc.userID); err != nil {
+							checkExpected(t, "select", err, services.ErrSeatNotAvailable, services.ErrBookingCancelled)
+						} else {
+							selected.Add(1)
+						}
+					case op < selectPercent+releasePercent:
+						// Освобождаются и чужие места: такая попытка должна завершиться отказом
+						if err := h.services.Booking.ReleaseSeat(ctx, seatID, acc.userID); err != nil {
+							checkExpected(t, "release", err, services.ErrSeatNotReserved, services.ErrNotBookingOwner)
+						} else {
+							released.Add(1)
+						}
+					default:
+						err := h.services.Booking.CancelBooking(ctx, &models.CancelBookingRequest{BookingID: bookingID}, acc.userID)
+						if err != nil {
+							checkExpected(t, "cancel", err, services.ErrBookingAlreadyCancelled)
+							continue
+						}
+						cancelled.Add(1)
+						// Бронь заменяет только тот воркер, который ее отменил
+						acc.booking.CompareAndSwap(bookingID, newBooking(acc.userID))
+					}
+				}
+			}()
+		}
+	}
+	close(start)
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	t.Logf("%d operations: %d seats selected, %d released, %d bookings cancelled, %d bookings created",
+		operations, selected.Load(), released.Load(), cancelled.Load(), bookingsCreated.Load())
+	if selected.Load() == 0 || released.Load() == 0 || cancelled.Load() == 0 {
+		t.Fatal("stress run did not exercise every operation")
+	}
+
+	reserved := h.assertSeatInvariants(t, eventID)
+
+	// Счетчики consumer'а сходятся с базой после обработки всех событий
+	eventually(t, func() error {
+		analytics, err := h.services.Analytics.GetAnalytics(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		stream := analytics.EventStream
+		if stream == nil {
+			return fmt.Errorf("no event stream stats")
+		}
+		if stream.BookingsCreated != bookingsCreated.Load() {
+			return fmt.Errorf("bookings_created = %d, want %d", stream.BookingsCreated, bookingsCreated.Load())
+		}
+		if held := stream.SeatsSelected - stream.SeatsReleased; held != int64(reserved) {
+			return fmt.Errorf("seats_selected - seats_released = %d, want %d reserved", held, reserved)
+		}
+		return nil
+	})
+
+	analytics, err := h.services.Analytics.GetAnalytics(ctx, eventID)
+	if err != nil {
+		t.Fatalf("failed to get analytics: %v", err)
+	}
+	if analytics.TotalSeats != len(seats) || analytics.ReservedSeats+analytics.SoldSeats != reserved ||
+		analytics.FreeSeats != len(seats)-reserved || analytics.BookingsCount != int(bookingsCreated.Load()) {
+		t.Fatalf("analytics = %+v, want %d seats, %d held, %d bookings", analytics, len(seats), reserved, bookingsCreated.Load())
+	}
+}
+
+// checkExpected пропускает ожидаемые бизнес-отказы операции (место занято, чужая бронь,
+// бронь отменена). Любая другая ошибка - взаимная блокировка, обрыв соединения или
+// нарушение ограничения под нагрузкой - это ошибка теста.
+func checkExpected(t *testing.T, operation string, err error, expected ...error) {
+	t.Helper()
+	for _, target := range expected {
+		if errors.Is(err, target) {
+			return
+		}
+	}
+	t.Errorf("%s: unexpected error: %v", operation, err)
+}
+
+// seatLinks возвращает брони, связанные с каждым местом мероприятия
+func (h *harness) seatLinks(t *testing.T, eventID int64) map[int64][]int64 {
+	t.Helper()
+
+	rows, err := h.db.Query(`
+		SELECT bs.seat_id, bs.booking_id
+		FROM booking_seats bs
+		JOIN seats s ON s.id = bs.seat_id
+		WHERE s.event_id = $1
+		ORDER BY bs.id`, eventID)
+	if err != nil {
+		t.Fatalf("failed to query booking seats: %v", err)
+	}
+	defer rows.Close()
+
+	links := make(map[int64][]int64)
+	for rows.Next() {
+		var seatID, bookingID int64
+		if err := rows.Scan(&seatID, &bookingID); err != nil {
+			t.Fatalf("failed to scan booking seat: %v", err)
+		}
+		links[seatID] = append(links[seatID], bookingID)
+	}
+	return links
+}
+
+// assertSeatInvariants проверяет согласованность мест и связей мероприятия и возвращает
+// количество занятых мест:
+//   - у каждого RESERVED/SOLD места ровно одна связь с бронью, у FREE - ни одной;
+//   - нет связей с несуществующей, отмененной или чужого мероприятия бронью;
+//   - стоимость брони равна сумме цен ее мест.
+func (h *harness) assertSeatInvariants(t *testing.T, eventID int64) int {
+	t.Helper()
+
+	links := h.seatLinks(t, eventID)
+	held := 0
+	for seatID, status := range h.seatStatuses(t, eventID) {
+		switch status {
+		case models.SeatStatusReserved, models.SeatStatusSold:
+			held++
+			if len(links[seatID]) != 1 {
+				t.Fatalf("%s seat %d is linked to bookings %v, want exactly one", status, seatID, links[seatID])
+			}
+		default:
+			if len(links[seatID]) != 0 {
+				t.Fatalf("%s seat %d is linked to bookings %v", status, seatID, links[seatID])
+			}
+		}
+	}
+
+	var orphans int
+	err := h.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM booking_seats bs
+		JOIN seats s ON s.id = bs.seat_id
+		LEFT JOIN bookings b ON b.id = bs.booking_id
+		WHERE s.event_id = $1
+		  AND (b.id IS NULL OR b.status = $2 OR b.event_id <> s.event_id)`,
+		eventID, models.BookingStatusCancelled).Scan(&orphans)
+	if err != nil {
+		t.Fatalf("failed to count orphan booking seats: %v", err)
+	}
+	if orphans != 0 {
+		t.Fatalf("%d booking seats point to missing, cancelled or foreign bookings", orphans)
+	}
+
+	// Стоимость каждой брони равна сумме цен ее мест (у отмененной мест нет)
+	var mispriced int
+	err = h.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM bookings b
+		WHERE b.event_id = $1
+		  AND b.total_amount <> COALESCE((
+			SELECT SUM(s.price)
+			FROM booking_seats bs
+			JOIN seats s ON s.id = bs.seat_id
+			WHERE bs.booking_id = b.id), 0)`, eventID).Scan(&mispriced)
+	if err != nil {
+		t.Fatalf("failed to count mispriced bookings: %v", err)
+	}
+	if mispriced != 0 {
+		t.Fatalf("%d bookings have total_amount different from the sum of their seat prices", mispriced)
+	}
+
+	return held
+}
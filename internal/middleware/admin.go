@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"biletter-service/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin пропускает только пользователей с ролью admin. Роль хранится в базе
+// и выдается вручную: email не подтверждается при регистрации и прав не дает.
+// Подключается после Auth, который кладет пользователя в контекст.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := GetCurrentUser(c)
+		if !ok {
+			abortUnauthorized(c, "User not found in context")
+			return
+		}
+
+		if user.Role != models.UserRoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -3,7 +3,9 @@ package middleware
 import (
 	"biletter-service/internal/models"
 	"biletter-service/internal/services"
+	"context"
 	"encoding/base64"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -12,59 +14,99 @@ import (
 
 const (
 	UserContextKey = "current_user"
+	TokenClaimsKey = "token_claims"
 )
 
 func BasicAuth(userService services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.Header("WWW-Authenticate", "Basic realm=\"Restricted\"")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			abortUnauthorized(c, "Authorization header required")
 			return
 		}
 
 		if !strings.HasPrefix(authHeader, "Basic ") {
-			c.Header("WWW-Authenticate", "Basic realm=\"Restricted\"")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			abortUnauthorized(c, "Invalid authorization header format")
 			return
 		}
 
-		encoded := strings.TrimPrefix(authHeader, "Basic ")
-		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		user, err := authenticateBasic(c.Request.Context(), userService, strings.TrimPrefix(authHeader, "Basic "))
 		if err != nil {
-			c.Header("WWW-Authenticate", "Basic realm=\"Restricted\"")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid base64 encoding"})
-			c.Abort()
+			abortUnauthorized(c, err.Error())
 			return
 		}
 
-		credentials := strings.SplitN(string(decoded), ":", 2)
-		if len(credentials) != 2 {
-			c.Header("WWW-Authenticate", "Basic realm=\"Restricted\"")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials format"})
-			c.Abort()
+		// Сохраняем пользователя в контексте
+		c.Set(UserContextKey, user)
+		c.Next()
+	}
+}
+
+// Auth принимает Bearer токены, выданные AuthService, и Basic Auth в качестве
+// запасного варианта для нагрузочного тестирования
+func Auth(authService services.AuthService, userService services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			abortUnauthorized(c, "Authorization header required")
 			return
 		}
 
-		email := credentials[0]
-		password := credentials[1]
+		switch {
+		case strings.HasPrefix(authHeader, "Bearer "):
+			// Пользователь берется из кэша: деактивированный или сменивший пароль теряет доступ сразу
+			claims, user, err := authService.ValidateAccessToken(c.Request.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+			if err != nil {
+				abortUnauthorized(c, "Invalid token")
+				return
+			}
 
-		user, err := userService.ValidateCredentials(email, password)
-		if err != nil {
-			c.Header("WWW-Authenticate", "Basic realm=\"Restricted\"")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-			c.Abort()
+			c.Set(TokenClaimsKey, claims)
+			c.Set(UserContextKey, user)
+		case strings.HasPrefix(authHeader, "Basic "):
+			user, err := authenticateBasic(c.Request.Context(), userService, strings.TrimPrefix(authHeader, "Basic "))
+			if err != nil {
+				abortUnauthorized(c, err.Error())
+				return
+			}
+			c.Set(UserContextKey, user)
+		default:
+			abortUnauthorized(c, "Invalid authorization header format")
 			return
 		}
 
-		// Сохраняем пользователя в контексте
-		c.Set(UserContextKey, user)
 		c.Next()
 	}
 }
 
+func authenticateBasic(ctx context.Context, userService services.UserService, encoded string) (*models.User, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("Invalid base64 encoding")
+	}
+
+	credentials := strings.SplitN(string(decoded), ":", 2)
+	if len(credentials) != 2 {
+		return nil, errors.New("Invalid credentials format")
+	}
+
+	email := credentials[0]
+	password := credentials[1]
+
+	user, err := userService.ValidateCredentials(ctx, email, password)
+	if err != nil {
+		return nil, errors.New("Invalid credentials")
+	}
+
+	return user, nil
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", "Basic realm=\"Restricted\"")
+	c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	c.Abort()
+}
+
 // GetCurrentUser извлекает текущего пользователя из контекста
 func GetCurrentUser(c *gin.Context) (*models.User, bool) {
 	user, exists := c.Get(UserContextKey)
@@ -75,3 +117,14 @@ func GetCurrentUser(c *gin.Context) (*models.User, bool) {
 	currentUser, ok := user.(*models.User)
 	return currentUser, ok
 }
+
+// GetTokenClaims извлекает claims токена, если запрос аутентифицирован через Bearer
+func GetTokenClaims(c *gin.Context) (*services.TokenClaims, bool) {
+	claims, exists := c.Get(TokenClaimsKey)
+	if !exists {
+		return nil, false
+	}
+
+	tokenClaims, ok := claims.(*services.TokenClaims)
+	return tokenClaims, ok
+}
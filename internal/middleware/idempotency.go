@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/pkg/cache"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyPrefix = "idempotency:"
+	maxIdempotencyKeyLen = 255
+
+	idempotencyStatusProcessing = "processing"
+	idempotencyStatusCompleted  = "completed"
+)
+
+// replayedHeaders заголовки ответа, которые сохраняются и воспроизводятся при повторе
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// idempotencyRecord сохраненное в Redis состояние запроса с ключом идемпотентности
+type idempotencyRecord struct {
+	Status      string            `json:"status"`
+	Fingerprint string            `json:"fingerprint"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// Idempotency воспроизводит исходный ответ при повторе запроса с тем же Idempotency-Key
+type Idempotency struct {
+	cacheClient cache.Cache
+	config      config.Idempotency
+	logger      *zap.Logger
+}
+
+func NewIdempotency(cacheClient cache.Cache, cfg config.Idempotency, logger *zap.Logger) *Idempotency {
+	return &Idempotency{
+		cacheClient: cacheClient,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Handler должен стоять после аутентификации: ключи разделены по пользователям
+func (m *Idempotency) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if m == nil || idempotencyKey == "" {
+			c.Next()
+			return
+		}
+
+		if len(idempotencyKey) > maxIdempotencyKeyLen {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		storageKey := m.storageKey(c, idempotencyKey)
+		fingerprint := requestFingerprint(c, body)
+
+		lock, _ := json.Marshal(idempotencyRecord{Status: idempotencyStatusProcessing, Fingerprint: fingerprint})
+		acquired, err := m.cacheClient.SetNX(ctx, storageKey, lock, m.config.LockTTL)
+		if err != nil {
+			// Без хранилища гарантировать идемпотентность нельзя, но и блокировать запросы не стоит
+			m.logger.Warn("Idempotency store unavailable", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			m.handleExisting(c, storageKey, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		m.saveResponse(ctx, storageKey, fingerprint, recorder)
+	}
+}
+
+func (m *Idempotency) handleExisting(c *gin.Context, storageKey, fingerprint string) {
+	raw, err := m.cacheClient.Get(c.Request.Context(), storageKey)
+	if err != nil {
+		// Запись истекла между SetNX и Get - просим клиента повторить
+		c.JSON(http.StatusConflict, gin.H{"error": "Request with this Idempotency-Key is being processed"})
+		c.Abort()
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		m.logger.Error("Corrupted idempotency record", zap.String("key", storageKey), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+		c.Abort()
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		c.Abort()
+		return
+	}
+
+	if record.Status != idempotencyStatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Request with this Idempotency-Key is being processed"})
+		c.Abort()
+		return
+	}
+
+	for name, value := range record.Headers {
+		c.Header(name, value)
+	}
+	c.Header(IdempotencyReplayedHeader, "true")
+	c.Status(record.StatusCode)
+	if len(record.Body) > 0 {
+		c.Writer.Write(record.Body)
+	}
+	c.Abort()
+}
+
+// saveResponse сохраняет ответ; при ошибке сервера ключ освобождается, чтобы клиент мог повторить запрос
+func (m *Idempotency) saveResponse(ctx context.Context, storageKey, fingerprint string, recorder *responseRecorder) {
+	// Запрос мог быть отменен клиентом, но ответ все равно нужно сохранить
+	ctx = context.WithoutCancel(ctx)
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		if err := m.cacheClient.Del(ctx, storageKey); err != nil {
+			m.logger.Warn("Failed to release idempotency key", zap.String("key", storageKey), zap.Error(err))
+		}
+		return
+	}
+
+	record := idempotencyRecord{
+		Status:      idempotencyStatusCompleted,
+		Fingerprint: fingerprint,
+		StatusCode:  recorder.Status(),
+		Headers:     make(map[string]string),
+		Body:        recorder.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			record.Headers[name] = value
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		m.logger.Error("Failed to marshal idempotency record", zap.Error(err))
+		return
+	}
+
+	if err := m.cacheClient.Set(ctx, storageKey, data, m.config.TTL); err != nil {
+		m.logger.Warn("Failed to store idempotent response", zap.String("key", storageKey), zap.Error(err))
+	}
+}
+
+func (m *Idempotency) storageKey(c *gin.Context, idempotencyKey string) string {
+	owner := "ip:" + c.ClientIP()
+	if user, ok := GetCurrentUser(c); ok {
+		owner = fmt.Sprintf("user:%d", user.UserID)
+	}
+
+	return fmt.Sprintf("%s%s:%s:%s", idempotencyKeyPrefix, owner, c.FullPath(), idempotencyKey)
+}
+
+// requestFingerprint хеш метода, маршрута и тела запроса
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.FullPath()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder дублирует тело ответа в буфер для последующего сохранения
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
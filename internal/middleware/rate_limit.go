@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"biletter-service/pkg/cache"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// tokenBucketScript атомарно пополняет корзину по прошедшему времени и забирает один токен.
+// Возвращает {1, 0} если запрос разрешен, иначе {0, миллисекунды до появления токена}.
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, retry}
+`
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimiter ограничивает частоту запросов по группам маршрутов.
+// Состояние хранится в Redis, поэтому лимиты общие для всех реплик.
+type RateLimiter struct {
+	cacheClient    cache.Cache
+	config         config.RateLimit
+	exemptRoles    map[models.UserRole]struct{}
+	exemptNetworks []*net.IPNet
+	logger         *zap.Logger
+}
+
+func NewRateLimiter(cacheClient cache.Cache, cfg config.RateLimit, logger *zap.Logger) *RateLimiter {
+	limiter := &RateLimiter{
+		cacheClient: cacheClient,
+		config:      cfg,
+		exemptRoles: make(map[models.UserRole]struct{}, len(cfg.ExemptRoles)),
+		logger:      logger,
+	}
+
+	for _, role := range cfg.ExemptRoles {
+		limiter.exemptRoles[models.UserRole(strings.ToLower(strings.TrimSpace(role)))] = struct{}{}
+	}
+
+	for _, cidr := range cfg.ExemptNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			logger.Warn("Invalid rate limit exempt network", zap.String("cidr", cidr), zap.Error(err))
+			continue
+		}
+		limiter.exemptNetworks = append(limiter.exemptNetworks, network)
+	}
+
+	return limiter
+}
+
+// Limit возвращает middleware для группы маршрутов. Если лимитер выключен или
+// группа не настроена, middleware пропускает все запросы.
+func (l *RateLimiter) Limit(group string) gin.HandlerFunc {
+	if l == nil || !l.config.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	rule, ok := l.config.Groups[group]
+	if !ok || rule.Rate <= 0 || rule.Burst <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		if l.isExempt(c) {
+			c.Next()
+			return
+		}
+
+		key := l.clientKey(c, group, rule)
+		allowed, retryAfter, err := l.take(c, key, rule)
+		if err != nil {
+			// При недоступности Redis не блокируем трафик
+			l.logger.Warn("Rate limiter unavailable", zap.String("group", group), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (l *RateLimiter) take(c *gin.Context, key string, rule config.RateLimitRule) (bool, time.Duration, error) {
+	result, err := l.cacheClient.Eval(c.Request.Context(), tokenBucketScript, []string{key},
+		rule.Rate, rule.Burst, time.Now().UnixMilli())
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limiter result: %v", result)
+	}
+
+	allowed, _ := values[0].(int64)
+	retryMs, _ := values[1].(int64)
+
+	return allowed == 1, time.Duration(retryMs) * time.Millisecond, nil
+}
+
+// clientKey выбирает ключ корзины: пользователь (если аутентифицирован) или IP клиента.
+// X-Forwarded-For учитывается в ClientIP только от прокси из app.trusted_proxies.
+func (l *RateLimiter) clientKey(c *gin.Context, group string, rule config.RateLimitRule) string {
+	if rule.By == "user" {
+		if user, ok := GetCurrentUser(c); ok {
+			return fmt.Sprintf("%s%s:user:%d", rateLimitKeyPrefix, group, user.UserID)
+		}
+	}
+
+	return fmt.Sprintf("%s%s:ip:%s", rateLimitKeyPrefix, group, c.ClientIP())
+}
+
+// isExempt пропускает пользователей с ролями из rate_limit.exempt_roles и запросы из сетей
+// rate_limit.exempt_networks. Роль хранится в базе: email при регистрации не подтверждается
+// и от лимитов не освобождает.
+func (l *RateLimiter) isExempt(c *gin.Context) bool {
+	if user, ok := GetCurrentUser(c); ok {
+		if _, exempt := l.exemptRoles[user.Role]; exempt {
+			return true
+		}
+	}
+
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range l.exemptNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"biletter-service/pkg/logger"
+	"biletter-service/pkg/requestid"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestLogger присваивает запросу X-Request-ID (или принимает его от клиента), кладет
+// в контекст запроса идентификатор и логгер с ним и после ответа пишет строку access-лога
+func RequestLogger(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Header(requestid.Header, id)
+
+		fields := []zap.Field{zap.String("request_id", id)}
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			fields = append(fields, zap.String("trace_id", span.TraceID().String()))
+		}
+		requestLogger := base.With(fields...)
+
+		ctx := requestid.NewContext(c.Request.Context(), id)
+		ctx = logger.NewContext(ctx, requestLogger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := zapcore.InfoLevel
+		switch {
+		case status >= 500:
+			level = zapcore.ErrorLevel
+		case status >= 400:
+			level = zapcore.WarnLevel
+		}
+
+		entry := requestLogger.Check(level, "HTTP request")
+		if entry == nil {
+			return
+		}
+
+		logFields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("response_size", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			logFields = append(logFields, zap.String("errors", c.Errors.String()))
+		}
+		entry.Write(logFields...)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"biletter-service/internal/config"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Timeouts ограничивает время обработки запроса дедлайном контекста.
+// Сервисы и репозитории получают c.Request.Context(), поэтому по истечении дедлайна
+// или при отключении клиента отменяются запросы к базе, Redis и внешним сервисам.
+type Timeouts struct {
+	config config.Timeouts
+	logger *zap.Logger
+}
+
+func NewTimeouts(cfg config.Timeouts, logger *zap.Logger) *Timeouts {
+	return &Timeouts{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// For возвращает middleware с дедлайном группы маршрутов или с дедлайном по умолчанию.
+// Дедлайны не складываются: вложенный контекст не может продлить дедлайн родителя,
+// поэтому middleware подключается один раз на маршрут.
+func (t *Timeouts) For(group string) gin.HandlerFunc {
+	timeout := t.timeout(group)
+	if timeout <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.logger.Warn("Request deadline exceeded",
+				zap.String("group", group),
+				zap.Duration("timeout", timeout),
+				zap.String("path", c.FullPath()))
+		}
+	}
+}
+
+func (t *Timeouts) timeout(group string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	if timeout, ok := t.config.Groups[group]; ok {
+		return timeout
+	}
+	return t.config.Default
+}
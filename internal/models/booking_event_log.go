@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BookingEventLogEntry запись журнала событий брони
+type BookingEventLogEntry struct {
+	ID           int64           `json:"id" db:"id"`
+	BookingID    int64           `json:"booking_id" db:"booking_id"`
+	EventID      string          `json:"event_id" db:"event_id"`
+	EventType    EventType       `json:"event_type" db:"event_type"`
+	EventVersion int             `json:"event_version" db:"event_version"`
+	Data         json.RawMessage `json:"data" db:"data"`
+	OccurredAt   time.Time       `json:"occurred_at" db:"occurred_at"`
+	RecordedAt   time.Time       `json:"recorded_at" db:"recorded_at"`
+}
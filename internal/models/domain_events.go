@@ -15,6 +15,7 @@ const (
 	BookingCancelledEvent EventType = "booking.cancelled"
 	SeatSelectedEvent     EventType = "seat.selected"
 	SeatReleasedEvent     EventType = "seat.released"
+	PaymentUpdatedEvent   EventType = "booking.payment_updated"
 )
 
 // DomainEvent базовая структура для всех доменных событий
@@ -29,31 +30,40 @@ type DomainEvent struct {
 
 // BookingCreatedData данные события создания брони
 type BookingCreatedData struct {
-	BookingID   int64 `json:"booking_id"`
-	EventID     int64 `json:"event_id"`
-	UserID      int   `json:"user_id"`
-	TotalAmount int64 `json:"total_amount"` // в копейках
+	BookingID   int64 `json:"booking_id" validate:"required"`
+	EventID     int64 `json:"event_id" validate:"required"`
+	UserID      int   `json:"user_id" validate:"required"`
+	TotalAmount int64 `json:"total_amount" validate:"gte=0"` // в копейках
 }
 
 // BookingCancelledData данные события отмены брони
 type BookingCancelledData struct {
-	BookingID int64  `json:"booking_id"`
-	UserID    int    `json:"user_id"`
+	BookingID int64  `json:"booking_id" validate:"required"`
+	UserID    int    `json:"user_id" validate:"required"`
 	Reason    string `json:"reason,omitempty"`
 }
 
 // SeatSelectedData данные события выбора места
 type SeatSelectedData struct {
-	BookingID int64 `json:"booking_id"`
-	SeatID    int64 `json:"seat_id"`
-	UserID    int   `json:"user_id"`
+	BookingID int64 `json:"booking_id" validate:"required"`
+	SeatID    int64 `json:"seat_id" validate:"required"`
+	UserID    int   `json:"user_id" validate:"required"`
 }
 
 // SeatReleasedData данные события освобождения места
 type SeatReleasedData struct {
-	BookingID int64 `json:"booking_id"`
-	SeatID    int64 `json:"seat_id"`
-	UserID    int   `json:"user_id"`
+	BookingID int64 `json:"booking_id" validate:"required"`
+	SeatID    int64 `json:"seat_id" validate:"required"`
+	UserID    int   `json:"user_id" validate:"required"`
+}
+
+// PaymentUpdatedData данные события изменения статуса оплаты брони
+type PaymentUpdatedData struct {
+	BookingID     int64         `json:"booking_id" validate:"required"`
+	UserID        int           `json:"user_id" validate:"required"`
+	Status        BookingStatus `json:"status" validate:"required"` // статус брони после изменения
+	PaymentID     string        `json:"payment_id,omitempty"`       // ID платежа в шлюзе, если известен
+	PaymentStatus string        `json:"payment_status,omitempty"`   // статус из уведомления шлюза
 }
 
 // NewDomainEvent создает новое доменное событие
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
 )
 
@@ -60,4 +62,71 @@ type AnalyticsResponse struct {
 	FreeSeats     int    `json:"free_seats"`
 	TotalRevenue  string `json:"total_revenue"`
 	BookingsCount int    `json:"bookings_count"`
+	// EventStream счетчики доменных событий мероприятия, которые ведет consumer;
+	// отстают от полей выше на задержку обработки событий, nil - счетчики не велись
+	EventStream *EventStreamStats `json:"event_stream,omitempty"`
+}
+
+// EventStreamStats счетчики доменных событий мероприятия из read model
+type EventStreamStats struct {
+	BookingsCreated   int64 `json:"bookings_created"`
+	BookingsCancelled int64 `json:"bookings_cancelled"`
+	SeatsSelected     int64 `json:"seats_selected"`
+	SeatsReleased     int64 `json:"seats_released"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RegisterUserRequest struct {
+	Email     string  `json:"email" binding:"required,email"`
+	Password  string  `json:"password" binding:"required,min=8,max=72"`
+	FirstName string  `json:"first_name" binding:"required,max=100"`
+	Surname   string  `json:"surname" binding:"required,max=100"`
+	Birthday  *string `json:"birthday" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1,max=100"`
+	Surname   *string `json:"surname" binding:"omitempty,min=1,max=100"`
+	Birthday  *string `json:"birthday" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// BookingHistoryResponse история брони, восстановленная из журнала событий
+type BookingHistoryResponse struct {
+	BookingID int64                    `json:"booking_id"`
+	EventID   int64                    `json:"event_id,omitempty"`
+	UserID    int                      `json:"user_id,omitempty"`
+	Status    BookingStatus            `json:"status,omitempty"`
+	PaymentID string                   `json:"payment_id,omitempty"`
+	Seats     []int64                  `json:"seats"`
+	Timeline  []BookingHistoryTimeItem `json:"timeline"`
+}
+
+// BookingHistoryTimeItem событие в истории брони
+type BookingHistoryTimeItem struct {
+	EventID     string      `json:"event_id"`
+	Type        EventType   `json:"type"`
+	Version     int         `json:"version"`
+	OccurredAt  time.Time   `json:"occurred_at"`
+	Description string      `json:"description"`
+	Data        interface{} `json:"data"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationKind string
+
+const (
+	NotificationBookingCreated   NotificationKind = "BOOKING_CREATED"
+	NotificationBookingCancelled NotificationKind = "BOOKING_CANCELLED"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationStatusPending NotificationStatus = "PENDING"
+	NotificationStatusSent    NotificationStatus = "SENT"
+	NotificationStatusFailed  NotificationStatus = "FAILED"
+)
+
+type NotificationJob struct {
+	ID        int64              `json:"id" db:"id"`
+	Kind      NotificationKind   `json:"kind" db:"kind"`
+	UserID    int                `json:"user_id" db:"user_id"`
+	BookingID int64              `json:"booking_id" db:"booking_id"`
+	Payload   json.RawMessage    `json:"payload" db:"payload"`
+	Status    NotificationStatus `json:"status" db:"status"`
+	Attempts  int                `json:"attempts" db:"attempts"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+	SentAt    *time.Time         `json:"sent_at" db:"sent_at"`
+}
@@ -4,6 +4,15 @@ import (
 	"time"
 )
 
+// UserRole роль пользователя; регистрация через API всегда создает UserRoleUser
+type UserRole string
+
+const (
+	UserRoleUser    UserRole = "user"
+	UserRoleAdmin   UserRole = "admin"   // доступ к /api/admin
+	UserRoleService UserRole = "service" // служебный аккаунт (нагрузочные тесты, интеграции)
+)
+
 type User struct {
 	UserID        int        `json:"user_id" db:"user_id"`
 	Email         string     `json:"email" db:"email"`
@@ -15,4 +24,5 @@ type User struct {
 	RegisteredAt  time.Time  `json:"registered_at" db:"registered_at"`
 	IsActive      bool       `json:"is_active" db:"is_active"`
 	LastLoggedIn  time.Time  `json:"last_logged_in" db:"last_logged_in"`
+	Role          UserRole   `json:"role" db:"role"`
 }
@@ -0,0 +1,131 @@
+// Package readmodel описывает данные в Redis, которые поддерживаются обработчиками
+// доменных событий и читаются HTTP сервисами: версии кеша мероприятий и мест, счетчики аналитики.
+package readmodel
+
+import (
+	"biletter-service/pkg/cache"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Поля счетчиков аналитики по мероприятию
+const (
+	StatBookingsCreated   = "bookings_created"
+	StatBookingsCancelled = "bookings_cancelled"
+	StatSeatsSelected     = "seats_selected"
+	StatSeatsReleased     = "seats_released"
+)
+
+const (
+	eventStatsKeyPrefix = "analytics:event:"
+	eventsKeyPrefix     = "events:"
+	seatsKeyPrefix      = "seats:"
+)
+
+// EventStatsKey ключ хеша со счетчиками мероприятия
+func EventStatsKey(eventID int64) string {
+	return fmt.Sprintf("%s%d", eventStatsKeyPrefix, eventID)
+}
+
+// IncrementStat изменяет счетчик мероприятия на delta
+func IncrementStat(ctx context.Context, c cache.Cache, eventID int64, field string, delta int64) error {
+	_, err := c.HIncrBy(ctx, EventStatsKey(eventID), field, delta)
+	return err
+}
+
+// EventStats возвращает счетчики мероприятия; ok=false, если счетчики еще не велись
+func EventStats(ctx context.Context, c cache.Cache, eventID int64) (map[string]int64, bool, error) {
+	raw, err := c.HGetAll(ctx, EventStatsKey(eventID))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(raw) == 0 {
+		return nil, false, nil
+	}
+
+	stats := make(map[string]int64, len(raw))
+	for field, value := range raw {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid counter %s=%q: %w", field, value, err)
+		}
+		stats[field] = n
+	}
+	return stats, true, nil
+}
+
+// seatsVersionKey хранит версию кеша мест мероприятия. Версия входит в ключи кеша,
+// поэтому инвалидация - это один INCR вместо сканирования ключей по шаблону.
+func seatsVersionKey(eventID int64) string {
+	return fmt.Sprintf("%s%d:version", seatsKeyPrefix, eventID)
+}
+
+// SeatsVersion возвращает текущую версию кеша мест мероприятия
+func SeatsVersion(ctx context.Context, c cache.Cache, eventID int64) (int64, error) {
+	value, err := c.Get(ctx, seatsVersionKey(eventID))
+	if errors.Is(err, redis.Nil) {
+		// Места мероприятия еще не менялись
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// SeatsCacheKey ключ закешированной страницы мест для версии version
+func SeatsCacheKey(eventID, version int64, status string, row, page, pageSize int64) string {
+	return fmt.Sprintf("%s%d:v%d:s:%s|r:%d|p:%d|ps:%d", seatsKeyPrefix, eventID, version, status, row, page, pageSize)
+}
+
+// InvalidateSeats делает недействительными все закешированные страницы мест мероприятия.
+// Старые ключи не удаляются и истекают по TTL.
+func InvalidateSeats(ctx context.Context, c cache.Cache, eventID int64) error {
+	_, err := c.Incr(ctx, seatsVersionKey(eventID))
+	return err
+}
+
+// eventsVersionKey хранит версию кеша списка мероприятий, устроенного так же, как кеш мест
+func eventsVersionKey() string {
+	return eventsKeyPrefix + "version"
+}
+
+// EventsVersion возвращает текущую версию кеша списка мероприятий
+func EventsVersion(ctx context.Context, c cache.Cache) (int64, error) {
+	value, err := c.Get(ctx, eventsVersionKey())
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// EventsCacheKey ключ закешированной страницы списка мероприятий для версии version;
+// query - хеш параметров поиска
+func EventsCacheKey(version int64, query string) string {
+	return fmt.Sprintf("%sv%d:%s", eventsKeyPrefix, version, query)
+}
+
+// InvalidateEvents делает недействительными все закешированные страницы списка мероприятий
+func InvalidateEvents(ctx context.Context, c cache.Cache) error {
+	_, err := c.Incr(ctx, eventsVersionKey())
+	return err
+}
+
+// Reset удаляет все счетчики и кеш мест и сбрасывает кеш мероприятий (используется при сбросе данных)
+func Reset(ctx context.Context, c cache.Cache) error {
+	if err := c.DelByPattern(ctx, eventStatsKeyPrefix+"*"); err != nil {
+		return err
+	}
+	if err := c.DelByPattern(ctx, seatsKeyPrefix+"*"); err != nil {
+		return err
+	}
+	// Версия не удаляется, а увеличивается: иначе после сброса снова читались бы страницы версии 0
+	return InvalidateEvents(ctx, c)
+}
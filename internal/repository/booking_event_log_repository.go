@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"biletter-service/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type BookingEventLogRepository interface {
+	Append(ctx context.Context, entry *models.BookingEventLogEntry) error
+	GetByBookingID(ctx context.Context, bookingID int64) ([]models.BookingEventLogEntry, error)
+	ListAfter(ctx context.Context, afterID int64, from time.Time, limit int) ([]models.BookingEventLogEntry, error)
+	WithTx(tx *sql.Tx) BookingEventLogRepository
+}
+
+type bookingEventLogRepository struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewBookingEventLogRepository(db *sql.DB) BookingEventLogRepository {
+	return &bookingEventLogRepository{db: db}
+}
+
+func (r *bookingEventLogRepository) WithTx(tx *sql.Tx) BookingEventLogRepository {
+	return &bookingEventLogRepository{db: r.db, tx: tx}
+}
+
+func (r *bookingEventLogRepository) getExecutor() interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Append добавляет событие в журнал; повторное добавление того же события игнорируется
+func (r *bookingEventLogRepository) Append(ctx context.Context, entry *models.BookingEventLogEntry) error {
+	query := `
+		INSERT INTO booking_event_log (booking_id, event_id, event_type, event_version, data, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id) DO NOTHING`
+
+	executor := r.getExecutor()
+	_, err := executor.ExecContext(ctx, query, entry.BookingID, entry.EventID, entry.EventType, entry.EventVersion,
+		[]byte(entry.Data), entry.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to append booking event: %w", err)
+	}
+
+	return nil
+}
+
+// GetByBookingID возвращает события брони в порядке возникновения
+func (r *bookingEventLogRepository) GetByBookingID(ctx context.Context, bookingID int64) ([]models.BookingEventLogEntry, error) {
+	query := `
+		SELECT id, booking_id, event_id, event_type, event_version, data, occurred_at, recorded_at
+		FROM booking_event_log
+		WHERE booking_id = $1
+		ORDER BY occurred_at, id`
+
+	return r.query(ctx, query, bookingID)
+}
+
+// ListAfter возвращает до limit событий с id > afterID и occurred_at >= from в порядке записи в журнал
+func (r *bookingEventLogRepository) ListAfter(ctx context.Context, afterID int64, from time.Time, limit int) ([]models.BookingEventLogEntry, error) {
+	query := `
+		SELECT id, booking_id, event_id, event_type, event_version, data, occurred_at, recorded_at
+		FROM booking_event_log
+		WHERE id > $1 AND occurred_at >= $2
+		ORDER BY id
+		LIMIT $3`
+
+	return r.query(ctx, query, afterID, from, limit)
+}
+
+func (r *bookingEventLogRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.BookingEventLogEntry, error) {
+	executor := r.getExecutor()
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query booking events: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.BookingEventLogEntry
+	for rows.Next() {
+		var entry models.BookingEventLogEntry
+		var data []byte
+		err := rows.Scan(&entry.ID, &entry.BookingID, &entry.EventID, &entry.EventType, &entry.EventVersion,
+			&data, &entry.OccurredAt, &entry.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking event: %w", err)
+		}
+		entry.Data = data
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate booking events: %w", err)
+	}
+
+	return entries, nil
+}
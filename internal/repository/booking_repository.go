@@ -2,21 +2,22 @@ package repository
 
 import (
 	"biletter-service/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 )
 
 type BookingRepository interface {
-	Create(booking *models.Booking) (*models.Booking, error)
-	GetByID(id int64) (*models.Booking, error)
-	GetByIDForUpdate(id int64) (*models.Booking, error)
-	Update(booking *models.Booking) error
-	GetByUserID(userID int) ([]models.Booking, error)
-	GetAll() ([]models.Booking, error)
-	GetByOrderID(orderID string) (*models.Booking, error)
-	DeleteAll() error
-	GetBookingStatistics(eventID int64) (int, string, error)
+	Create(ctx context.Context, booking *models.Booking) (*models.Booking, error)
+	GetByID(ctx context.Context, id int64) (*models.Booking, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Booking, error)
+	Update(ctx context.Context, booking *models.Booking) error
+	GetByUserID(ctx context.Context, userID int) ([]models.Booking, error)
+	GetAll(ctx context.Context) ([]models.Booking, error)
+	GetByOrderID(ctx context.Context, orderID string) (*models.Booking, error)
+	DeleteAll(ctx context.Context) error
+	GetBookingStatistics(ctx context.Context, eventID int64) (int, string, error)
 	WithTx(tx *sql.Tx) BookingRepository
 }
 
@@ -34,9 +35,9 @@ func (r *bookingRepository) WithTx(tx *sql.Tx) BookingRepository {
 }
 
 func (r *bookingRepository) getExecutor() interface {
-	QueryRow(query string, args ...interface{}) *sql.Row
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 } {
 	if r.tx != nil {
 		return r.tx
@@ -44,7 +45,7 @@ func (r *bookingRepository) getExecutor() interface {
 	return r.db
 }
 
-func (r *bookingRepository) Create(booking *models.Booking) (*models.Booking, error) {
+func (r *bookingRepository) Create(ctx context.Context, booking *models.Booking) (*models.Booking, error) {
 	query := `
 		INSERT INTO bookings (event_id, user_id, status, total_amount, payment_id, order_id, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -55,7 +56,7 @@ func (r *bookingRepository) Create(booking *models.Booking) (*models.Booking, er
 	booking.UpdatedAt = now
 
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, booking.EventID, booking.UserID, booking.Status,
+	err := executor.QueryRowContext(ctx, query, booking.EventID, booking.UserID, booking.Status,
 		booking.TotalAmount, booking.PaymentID, booking.OrderID, booking.CreatedAt, booking.UpdatedAt).Scan(&booking.ID)
 
 	if err != nil {
@@ -65,14 +66,14 @@ func (r *bookingRepository) Create(booking *models.Booking) (*models.Booking, er
 	return booking, nil
 }
 
-func (r *bookingRepository) GetByID(id int64) (*models.Booking, error) {
+func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*models.Booking, error) {
 	query := `
 		SELECT id, event_id, user_id, status, total_amount, payment_id, order_id, created_at, updated_at
 		FROM bookings WHERE id = $1`
 
 	var booking models.Booking
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, id).Scan(&booking.ID, &booking.EventID, &booking.UserID,
+	err := executor.QueryRowContext(ctx, query, id).Scan(&booking.ID, &booking.EventID, &booking.UserID,
 		&booking.Status, &booking.TotalAmount, &booking.PaymentID, &booking.OrderID,
 		&booking.CreatedAt, &booking.UpdatedAt)
 
@@ -86,14 +87,14 @@ func (r *bookingRepository) GetByID(id int64) (*models.Booking, error) {
 	return &booking, nil
 }
 
-func (r *bookingRepository) GetByIDForUpdate(id int64) (*models.Booking, error) {
+func (r *bookingRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Booking, error) {
 	query := `
 		SELECT id, event_id, user_id, status, total_amount, payment_id, order_id, created_at, updated_at
 		FROM bookings WHERE id = $1 FOR UPDATE`
 
 	var booking models.Booking
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, id).Scan(&booking.ID, &booking.EventID, &booking.UserID,
+	err := executor.QueryRowContext(ctx, query, id).Scan(&booking.ID, &booking.EventID, &booking.UserID,
 		&booking.Status, &booking.TotalAmount, &booking.PaymentID, &booking.OrderID,
 		&booking.CreatedAt, &booking.UpdatedAt)
 
@@ -107,7 +108,7 @@ func (r *bookingRepository) GetByIDForUpdate(id int64) (*models.Booking, error)
 	return &booking, nil
 }
 
-func (r *bookingRepository) Update(booking *models.Booking) error {
+func (r *bookingRepository) Update(ctx context.Context, booking *models.Booking) error {
 	query := `
 		UPDATE bookings 
 		SET status = $1, total_amount = $2, payment_id = $3, order_id = $4, updated_at = $5
@@ -116,7 +117,7 @@ func (r *bookingRepository) Update(booking *models.Booking) error {
 	booking.UpdatedAt = time.Now()
 
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, booking.Status, booking.TotalAmount, booking.PaymentID,
+	_, err := executor.ExecContext(ctx, query, booking.Status, booking.TotalAmount, booking.PaymentID,
 		booking.OrderID, booking.UpdatedAt, booking.ID)
 
 	if err != nil {
@@ -126,13 +127,13 @@ func (r *bookingRepository) Update(booking *models.Booking) error {
 	return nil
 }
 
-func (r *bookingRepository) GetByUserID(userID int) ([]models.Booking, error) {
+func (r *bookingRepository) GetByUserID(ctx context.Context, userID int) ([]models.Booking, error) {
 	query := `
 		SELECT id, event_id, user_id, status, total_amount, payment_id, order_id, created_at, updated_at
 		FROM bookings WHERE user_id = $1 ORDER BY created_at DESC`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, userID)
+	rows, err := executor.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query bookings by user: %w", err)
 	}
@@ -153,13 +154,13 @@ func (r *bookingRepository) GetByUserID(userID int) ([]models.Booking, error) {
 	return bookings, nil
 }
 
-func (r *bookingRepository) GetAll() ([]models.Booking, error) {
+func (r *bookingRepository) GetAll(ctx context.Context) ([]models.Booking, error) {
 	query := `
 		SELECT id, event_id, user_id, status, total_amount, payment_id, order_id, created_at, updated_at
 		FROM bookings ORDER BY created_at DESC`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query)
+	rows, err := executor.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query all bookings: %w", err)
 	}
@@ -180,14 +181,14 @@ func (r *bookingRepository) GetAll() ([]models.Booking, error) {
 	return bookings, nil
 }
 
-func (r *bookingRepository) GetByOrderID(orderID string) (*models.Booking, error) {
+func (r *bookingRepository) GetByOrderID(ctx context.Context, orderID string) (*models.Booking, error) {
 	query := `
 		SELECT id, event_id, user_id, status, total_amount, payment_id, order_id, created_at, updated_at
 		FROM bookings WHERE order_id = $1`
 
 	var booking models.Booking
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, orderID).Scan(&booking.ID, &booking.EventID, &booking.UserID,
+	err := executor.QueryRowContext(ctx, query, orderID).Scan(&booking.ID, &booking.EventID, &booking.UserID,
 		&booking.Status, &booking.TotalAmount, &booking.PaymentID, &booking.OrderID,
 		&booking.CreatedAt, &booking.UpdatedAt)
 
@@ -201,17 +202,17 @@ func (r *bookingRepository) GetByOrderID(orderID string) (*models.Booking, error
 	return &booking, nil
 }
 
-func (r *bookingRepository) DeleteAll() error {
+func (r *bookingRepository) DeleteAll(ctx context.Context) error {
 	executor := r.getExecutor()
 
 	// Сначала удаляем все booking_seats (FK constraint)
-	_, err := executor.Exec("DELETE FROM booking_seats")
+	_, err := executor.ExecContext(ctx, "DELETE FROM booking_seats")
 	if err != nil {
 		return fmt.Errorf("failed to delete booking_seats: %w", err)
 	}
 
 	// Затем удаляем все bookings
-	_, err = executor.Exec("DELETE FROM bookings")
+	_, err = executor.ExecContext(ctx, "DELETE FROM bookings")
 	if err != nil {
 		return fmt.Errorf("failed to delete bookings: %w", err)
 	}
@@ -219,12 +220,12 @@ func (r *bookingRepository) DeleteAll() error {
 	return nil
 }
 
-func (r *bookingRepository) GetBookingStatistics(eventID int64) (int, string, error) {
+func (r *bookingRepository) GetBookingStatistics(ctx context.Context, eventID int64) (int, string, error) {
 	query := `SELECT COUNT(*) FROM bookings WHERE event_id = $1`
 
 	executor := r.getExecutor()
 	var count int
-	err := executor.QueryRow(query, eventID).Scan(&count)
+	err := executor.QueryRowContext(ctx, query, eventID).Scan(&count)
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to get booking statistics: %w", err)
 	}
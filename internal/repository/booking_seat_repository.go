@@ -2,6 +2,7 @@ package repository
 
 import (
 	"biletter-service/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -10,13 +11,13 @@ import (
 )
 
 type BookingSeatRepository interface {
-	Create(booingSeat *models.BookingSeat) (*models.BookingSeat, error)
-	GetByID(id int64) (*models.BookingSeat, error)
-	GetBySeatID(seatID int64) ([]*models.BookingSeat, error)
-	GetByBookingID(bookingID int64) ([]*models.BookingSeat, error)
-	GetByBookingIDs(bookingIDs []int64) ([]*models.BookingSeat, error)
-	Update(bookingSeat *models.BookingSeat) (*models.BookingSeat, error)
-	Delete(id int64) error
+	Create(ctx context.Context, booingSeat *models.BookingSeat) (*models.BookingSeat, error)
+	GetByID(ctx context.Context, id int64) (*models.BookingSeat, error)
+	GetBySeatID(ctx context.Context, seatID int64) ([]*models.BookingSeat, error)
+	GetByBookingID(ctx context.Context, bookingID int64) ([]*models.BookingSeat, error)
+	GetByBookingIDs(ctx context.Context, bookingIDs []int64) ([]*models.BookingSeat, error)
+	Update(ctx context.Context, bookingSeat *models.BookingSeat) (*models.BookingSeat, error)
+	Delete(ctx context.Context, id int64) error
 	WithTx(txt *sql.Tx) BookingSeatRepository
 }
 
@@ -34,9 +35,9 @@ func (r *bookingSeatRepository) WithTx(tx *sql.Tx) BookingSeatRepository {
 }
 
 func (r *bookingSeatRepository) getExecutor() interface {
-	QueryRow(query string, args ...interface{}) *sql.Row
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 } {
 	if r.tx != nil {
 		return r.tx
@@ -44,7 +45,7 @@ func (r *bookingSeatRepository) getExecutor() interface {
 	return r.db
 }
 
-func (r *bookingSeatRepository) Create(bookingSeat *models.BookingSeat) (*models.BookingSeat, error) {
+func (r *bookingSeatRepository) Create(ctx context.Context, bookingSeat *models.BookingSeat) (*models.BookingSeat, error) {
 	query := `
 		INSERT INTO booking_seats(booking_id, seat_id, created_at)
 		VALUES ($1, $2, $3)
@@ -54,7 +55,7 @@ func (r *bookingSeatRepository) Create(bookingSeat *models.BookingSeat) (*models
 	bookingSeat.CreatedAt = now
 
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, bookingSeat.BookingID, bookingSeat.SeatID, now).Scan(&bookingSeat.ID)
+	err := executor.QueryRowContext(ctx, query, bookingSeat.BookingID, bookingSeat.SeatID, now).Scan(&bookingSeat.ID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bookingSeat: %w", err)
@@ -63,14 +64,14 @@ func (r *bookingSeatRepository) Create(bookingSeat *models.BookingSeat) (*models
 	return bookingSeat, nil
 }
 
-func (r *bookingSeatRepository) GetByID(id int64) (*models.BookingSeat, error) {
+func (r *bookingSeatRepository) GetByID(ctx context.Context, id int64) (*models.BookingSeat, error) {
 	query := `
 		SELECT id, booking_id, seat_id, created_at
 		FROM booking_seats WHERE id = $1`
 
 	var bookingSeat models.BookingSeat
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, id).Scan(&bookingSeat.ID, &bookingSeat.BookingID, &bookingSeat.SeatID, &bookingSeat.CreatedAt)
+	err := executor.QueryRowContext(ctx, query, id).Scan(&bookingSeat.ID, &bookingSeat.BookingID, &bookingSeat.SeatID, &bookingSeat.CreatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookingSeat: %w", err)
@@ -79,13 +80,13 @@ func (r *bookingSeatRepository) GetByID(id int64) (*models.BookingSeat, error) {
 	return &bookingSeat, nil
 }
 
-func (r *bookingSeatRepository) GetBySeatID(seatID int64) ([]*models.BookingSeat, error) {
+func (r *bookingSeatRepository) GetBySeatID(ctx context.Context, seatID int64) ([]*models.BookingSeat, error) {
 	query := `
 		SELECT id, booking_id, seat_id, created_at
 		FROM booking_seats WHERE seat_id = $1`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, seatID)
+	rows, err := executor.QueryContext(ctx, query, seatID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookingSeats by seatID: %w", err)
@@ -105,13 +106,13 @@ func (r *bookingSeatRepository) GetBySeatID(seatID int64) ([]*models.BookingSeat
 	return bookingSeats, nil
 }
 
-func (r *bookingSeatRepository) GetByBookingID(bookingID int64) ([]*models.BookingSeat, error) {
+func (r *bookingSeatRepository) GetByBookingID(ctx context.Context, bookingID int64) ([]*models.BookingSeat, error) {
 	query := `
 		SELECT id, booking_id, seat_id, created_at
 		FROM booking_seats WHERE booking_id = $1`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, bookingID)
+	rows, err := executor.QueryContext(ctx, query, bookingID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookingSeats by bookingID: %w", err)
@@ -130,7 +131,7 @@ func (r *bookingSeatRepository) GetByBookingID(bookingID int64) ([]*models.Booki
 	return bookingSeats, nil
 }
 
-func (r *bookingSeatRepository) GetByBookingIDs(bookingIDs []int64) ([]*models.BookingSeat, error) {
+func (r *bookingSeatRepository) GetByBookingIDs(ctx context.Context, bookingIDs []int64) ([]*models.BookingSeat, error) {
 	if len(bookingIDs) == 0 {
 		return []*models.BookingSeat{}, nil
 	}
@@ -140,7 +141,7 @@ func (r *bookingSeatRepository) GetByBookingIDs(bookingIDs []int64) ([]*models.B
 		FROM booking_seats WHERE booking_id = ANY($1)`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, pq.Array(bookingIDs))
+	rows, err := executor.QueryContext(ctx, query, pq.Array(bookingIDs))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookingSeats by bookingID: %w", err)
@@ -159,13 +160,13 @@ func (r *bookingSeatRepository) GetByBookingIDs(bookingIDs []int64) ([]*models.B
 	return bookingSeats, nil
 }
 
-func (r *bookingSeatRepository) Update(bookingSeat *models.BookingSeat) (*models.BookingSeat, error) {
+func (r *bookingSeatRepository) Update(ctx context.Context, bookingSeat *models.BookingSeat) (*models.BookingSeat, error) {
 	query := `
 		UPDATE booking_seats SET booking_id = $1, seat_id = $2
 		WHERE id = $3`
 
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, bookingSeat.BookingID, bookingSeat.SeatID, bookingSeat.ID)
+	_, err := executor.ExecContext(ctx, query, bookingSeat.BookingID, bookingSeat.SeatID, bookingSeat.ID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update bookingSeat: %w", err)
@@ -174,11 +175,11 @@ func (r *bookingSeatRepository) Update(bookingSeat *models.BookingSeat) (*models
 	return bookingSeat, nil
 }
 
-func (r *bookingSeatRepository) Delete(id int64) error {
+func (r *bookingSeatRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM booking_seats WHERE id = $1`
 
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, id)
+	_, err := executor.ExecContext(ctx, query, id)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete bookingSeat: %w", err)
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"biletter-service/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -9,8 +10,8 @@ import (
 )
 
 type EventRepository interface {
-	FindEvents(query *string, date *time.Time, page, pageSize int) ([]models.Event, error)
-	GetByID(id int64) (*models.Event, error)
+	FindEvents(ctx context.Context, query *string, date *time.Time, page, pageSize int) ([]models.Event, error)
+	GetByID(ctx context.Context, id int64) (*models.Event, error)
 	WithTx(tx *sql.Tx) EventRepository
 }
 
@@ -28,9 +29,9 @@ func (r *eventRepository) WithTx(tx *sql.Tx) EventRepository {
 }
 
 func (r *eventRepository) getExecutor() interface {
-	QueryRow(query string, args ...interface{}) *sql.Row
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 } {
 	if r.tx != nil {
 		return r.tx
@@ -38,7 +39,7 @@ func (r *eventRepository) getExecutor() interface {
 	return r.db
 }
 
-func (r *eventRepository) FindEvents(query *string, date *time.Time, page, pageSize int) ([]models.Event, error) {
+func (r *eventRepository) FindEvents(ctx context.Context, query *string, date *time.Time, page, pageSize int) ([]models.Event, error) {
 	var conditions []string
 	var args []interface{}
 	argIndex := 1
@@ -83,7 +84,7 @@ func (r *eventRepository) FindEvents(query *string, date *time.Time, page, pageS
 	args = append(args, pageSize, offset)
 
 	//executor := r.getExecutor() // в целях оптимизации убрал вызов через executor
-	rows, err := r.db.Query(baseQuery, args...)
+	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
@@ -103,12 +104,12 @@ func (r *eventRepository) FindEvents(query *string, date *time.Time, page, pageS
 	return events, nil
 }
 
-func (r *eventRepository) GetByID(id int64) (*models.Event, error) {
+func (r *eventRepository) GetByID(ctx context.Context, id int64) (*models.Event, error) {
 	query := `SELECT id, title, description, type, datetime_start, provider FROM events WHERE id = $1`
 
 	var event models.Event
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, id).Scan(&event.ID, &event.Title, &event.Description,
+	err := executor.QueryRowContext(ctx, query, id).Scan(&event.ID, &event.Title, &event.Description,
 		&event.Type, &event.DatetimeStart, &event.Provider)
 
 	if err != nil {
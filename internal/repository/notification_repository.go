@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"biletter-service/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type NotificationRepository interface {
+	Enqueue(ctx context.Context, job *models.NotificationJob) (*models.NotificationJob, error)
+	WithTx(tx *sql.Tx) NotificationRepository
+}
+
+type notificationRepository struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) WithTx(tx *sql.Tx) NotificationRepository {
+	return &notificationRepository{db: r.db, tx: tx}
+}
+
+func (r *notificationRepository) getExecutor() interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Enqueue ставит задание на отправку уведомления в очередь со статусом PENDING
+func (r *notificationRepository) Enqueue(ctx context.Context, job *models.NotificationJob) (*models.NotificationJob, error) {
+	query := `
+		INSERT INTO notification_jobs (kind, user_id, booking_id, payload, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, attempts, created_at`
+
+	payload := job.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	executor := r.getExecutor()
+	created := *job
+	created.Payload = payload
+	created.Status = models.NotificationStatusPending
+
+	err := executor.QueryRowContext(ctx, query, created.Kind, created.UserID, created.BookingID, []byte(payload), created.Status).
+		Scan(&created.ID, &created.Attempts, &created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	return &created, nil
+}
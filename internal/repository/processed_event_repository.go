@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type ProcessedEventRepository interface {
+	MarkProcessed(ctx context.Context, eventID, consumerGroup, eventType string) (bool, error)
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteByGroup(ctx context.Context, consumerGroup string) (int64, error)
+	WithTx(tx *sql.Tx) ProcessedEventRepository
+}
+
+type processedEventRepository struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewProcessedEventRepository(db *sql.DB) ProcessedEventRepository {
+	return &processedEventRepository{db: db}
+}
+
+func (r *processedEventRepository) WithTx(tx *sql.Tx) ProcessedEventRepository {
+	return &processedEventRepository{db: r.db, tx: tx}
+}
+
+func (r *processedEventRepository) getExecutor() interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// MarkProcessed отмечает событие обработанным группой consumer'ов.
+// Возвращает false, если событие уже было обработано этой группой.
+// Вызывается в транзакции обработчика, чтобы отметка и побочные эффекты фиксировались вместе.
+func (r *processedEventRepository) MarkProcessed(ctx context.Context, eventID, consumerGroup, eventType string) (bool, error) {
+	query := `
+		INSERT INTO processed_events (event_id, consumer_group, event_type, processed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id, consumer_group) DO NOTHING`
+
+	executor := r.getExecutor()
+	result, err := executor.ExecContext(ctx, query, eventID, consumerGroup, eventType, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to mark event as processed: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// DeleteProcessedBefore удаляет отметки старше заданного момента
+func (r *processedEventRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM processed_events WHERE processed_at < $1`
+
+	executor := r.getExecutor()
+	result, err := executor.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// DeleteByGroup удаляет отметки группы consumer'ов: после этого группа заново применит
+// все события, например при перестроении проекции с нуля
+func (r *processedEventRepository) DeleteByGroup(ctx context.Context, consumerGroup string) (int64, error) {
+	query := `DELETE FROM processed_events WHERE consumer_group = $1`
+
+	executor := r.getExecutor()
+	result, err := executor.ExecContext(ctx, query, consumerGroup)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed events of group: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type ReplayCheckpointRepository interface {
+	Get(ctx context.Context, name string) (int64, bool, error)
+	Save(ctx context.Context, name string, position int64) error
+}
+
+type replayCheckpointRepository struct {
+	db *sql.DB
+}
+
+func NewReplayCheckpointRepository(db *sql.DB) ReplayCheckpointRepository {
+	return &replayCheckpointRepository{db: db}
+}
+
+// Get возвращает сохраненную позицию проигрывания; false, если прогресса еще нет
+func (r *replayCheckpointRepository) Get(ctx context.Context, name string) (int64, bool, error) {
+	query := `SELECT position FROM replay_checkpoints WHERE name = $1`
+
+	var position int64
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get replay checkpoint: %w", err)
+	}
+
+	return position, true, nil
+}
+
+// Save сохраняет позицию проигрывания
+func (r *replayCheckpointRepository) Save(ctx context.Context, name string, position int64) error {
+	query := `
+		INSERT INTO replay_checkpoints (name, position, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET position = EXCLUDED.position, updated_at = NOW()`
+
+	if _, err := r.db.ExecContext(ctx, query, name, position); err != nil {
+		return fmt.Errorf("failed to save replay checkpoint: %w", err)
+	}
+
+	return nil
+}
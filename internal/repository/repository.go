@@ -1,30 +1,57 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"sync/atomic"
 )
 
 type Repository struct {
-	Event       EventRepository
-	Seat        SeatRepository
-	Booking     BookingRepository
-	BookingSeat BookingSeatRepository
-	User        UserRepository
-	TxManager   *TransactionManager
+	Event          EventRepository
+	Seat           SeatRepository
+	Booking        BookingRepository
+	BookingSeat    BookingSeatRepository
+	User           UserRepository
+	ProcessedEvent ProcessedEventRepository
+	Notification   NotificationRepository
+	BookingEvents  BookingEventLogRepository
+	Checkpoint     ReplayCheckpointRepository
+	TxManager      *TransactionManager
+
+	cacheWarm atomic.Bool
 }
 
 func New(db *sql.DB) *Repository {
 	return &Repository{
-		Event:       NewEventRepository(db),
-		Seat:        NewSeatRepository(db),
-		Booking:     NewBookingRepository(db),
-		BookingSeat: NewBookingSeatRepository(db),
-		User:        NewUserRepository(db),
-		TxManager:   NewTransactionManager(db),
+		Event:          NewEventRepository(db),
+		Seat:           NewSeatRepository(db),
+		Booking:        NewBookingRepository(db),
+		BookingSeat:    NewBookingSeatRepository(db),
+		User:           NewUserRepository(db),
+		ProcessedEvent: NewProcessedEventRepository(db),
+		Notification:   NewNotificationRepository(db),
+		BookingEvents:  NewBookingEventLogRepository(db),
+		Checkpoint:     NewReplayCheckpointRepository(db),
+		TxManager:      NewTransactionManager(db),
 	}
 }
 
 // InitializeCache предзагружает кэши при старте приложения
-func (r *Repository) InitializeCache() error {
-	return r.User.PreloadCache()
+func (r *Repository) InitializeCache(ctx context.Context) error {
+	if err := r.User.PreloadCache(ctx); err != nil {
+		return err
+	}
+
+	r.cacheWarm.Store(true)
+	return nil
+}
+
+// CacheWarm сообщает, завершилась ли предзагрузка кэшей
+func (r *Repository) CacheWarm() bool {
+	return r.cacheWarm.Load()
+}
+
+// UserCacheSize возвращает количество пользователей в кэше
+func (r *Repository) UserCacheSize() int {
+	return globalUserCache.Len()
 }
@@ -2,23 +2,24 @@ package repository
 
 import (
 	"biletter-service/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 )
 
 type SeatRepository interface {
-	GetByEventID(eventID int64, status string, row int64, page int64, pageSize int64) ([]models.Seat, error)
-	GetByID(id int64) (*models.Seat, error)
-	GetByIDForUpdate(id int64) (*models.Seat, error)
-	GetByIDs(ids []int64) ([]models.Seat, error)
-	UpdateStatus(seatID int64, status models.SeatStatus) error
-	Update(seat *models.Seat) error
-	ReserveSeats(seatIDs []int64, userID int) error
-	ReleaseSeats(seatIDs []int64) error
-	ResetAllStatus() error
-	GetSeatStatistics(eventID int64) (map[string]int, string, error)
-	Save(s models.Seat) error
+	GetByEventID(ctx context.Context, eventID int64, status string, row int64, page int64, pageSize int64) ([]models.Seat, error)
+	GetByID(ctx context.Context, id int64) (*models.Seat, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Seat, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]models.Seat, error)
+	UpdateStatus(ctx context.Context, seatID int64, status models.SeatStatus) error
+	Update(ctx context.Context, seat *models.Seat) error
+	ReserveSeats(ctx context.Context, seatIDs []int64, userID int) error
+	ReleaseSeats(ctx context.Context, seatIDs []int64) error
+	ResetAllStatus(ctx context.Context) error
+	GetSeatStatistics(ctx context.Context, eventID int64) (map[string]int, string, error)
+	Save(ctx context.Context, s models.Seat) error
 	WithTx(tx *sql.Tx) SeatRepository
 }
 
@@ -36,9 +37,9 @@ func (r *seatRepository) WithTx(tx *sql.Tx) SeatRepository {
 }
 
 func (r *seatRepository) getExecutor() interface {
-	QueryRow(query string, args ...interface{}) *sql.Row
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 } {
 	if r.tx != nil {
 		return r.tx
@@ -46,7 +47,7 @@ func (r *seatRepository) getExecutor() interface {
 	return r.db
 }
 
-func (r *seatRepository) GetByEventID(eventID int64, status string, row int64, page int64, pageSize int64) ([]models.Seat, error) {
+func (r *seatRepository) GetByEventID(ctx context.Context, eventID int64, status string, row int64, page int64, pageSize int64) ([]models.Seat, error) {
 	query := `
 		SELECT id, event_id, row_number, seat_number, status, price, created_at, updated_at, version
 		FROM seats
@@ -71,7 +72,7 @@ func (r *seatRepository) GetByEventID(eventID int64, status string, row int64, p
 	args = append(args, pageSize, (page-1)*pageSize)
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, args...)
+	rows, err := executor.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query seats: %w", err)
 	}
@@ -91,14 +92,14 @@ func (r *seatRepository) GetByEventID(eventID int64, status string, row int64, p
 	return seats, nil
 }
 
-func (r *seatRepository) GetByID(id int64) (*models.Seat, error) {
+func (r *seatRepository) GetByID(ctx context.Context, id int64) (*models.Seat, error) {
 	query := `
 		SELECT id, event_id, row_number, seat_number, status, price, created_at, updated_at, version
 		FROM seats WHERE id = $1`
 
 	var seat models.Seat
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, id).Scan(&seat.ID, &seat.EventID, &seat.RowNumber,
+	err := executor.QueryRowContext(ctx, query, id).Scan(&seat.ID, &seat.EventID, &seat.RowNumber,
 		&seat.SeatNumber, &seat.Status, &seat.Price, &seat.CreatedAt, &seat.UpdatedAt, &seat.Version)
 
 	if err != nil {
@@ -111,14 +112,14 @@ func (r *seatRepository) GetByID(id int64) (*models.Seat, error) {
 	return &seat, nil
 }
 
-func (r *seatRepository) GetByIDForUpdate(id int64) (*models.Seat, error) {
+func (r *seatRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Seat, error) {
 	query := `
 		SELECT id, event_id, row_number, seat_number, status, price, created_at, updated_at, version
 		FROM seats WHERE id = $1 FOR UPDATE`
 
 	var seat models.Seat
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, id).Scan(&seat.ID, &seat.EventID, &seat.RowNumber,
+	err := executor.QueryRowContext(ctx, query, id).Scan(&seat.ID, &seat.EventID, &seat.RowNumber,
 		&seat.SeatNumber, &seat.Status, &seat.Price, &seat.CreatedAt, &seat.UpdatedAt, &seat.Version)
 
 	if err != nil {
@@ -131,7 +132,7 @@ func (r *seatRepository) GetByIDForUpdate(id int64) (*models.Seat, error) {
 	return &seat, nil
 }
 
-func (r *seatRepository) GetByIDs(ids []int64) ([]models.Seat, error) {
+func (r *seatRepository) GetByIDs(ctx context.Context, ids []int64) ([]models.Seat, error) {
 	if len(ids) == 0 {
 		return []models.Seat{}, nil
 	}
@@ -141,7 +142,7 @@ func (r *seatRepository) GetByIDs(ids []int64) ([]models.Seat, error) {
 		FROM seats WHERE id = ANY($1)`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, ids)
+	rows, err := executor.QueryContext(ctx, query, ids)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query seats by IDs: %w", err)
 	}
@@ -161,11 +162,11 @@ func (r *seatRepository) GetByIDs(ids []int64) ([]models.Seat, error) {
 	return seats, nil
 }
 
-func (r *seatRepository) UpdateStatus(seatID int64, status models.SeatStatus) error {
+func (r *seatRepository) UpdateStatus(ctx context.Context, seatID int64, status models.SeatStatus) error {
 	query := `UPDATE seats SET status = $1, updated_at = $2 WHERE id = $3`
 
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, status, time.Now(), seatID)
+	_, err := executor.ExecContext(ctx, query, status, time.Now(), seatID)
 	if err != nil {
 		return fmt.Errorf("failed to update seat status: %w", err)
 	}
@@ -173,7 +174,7 @@ func (r *seatRepository) UpdateStatus(seatID int64, status models.SeatStatus) er
 	return nil
 }
 
-func (r *seatRepository) Update(seat *models.Seat) error {
+func (r *seatRepository) Update(ctx context.Context, seat *models.Seat) error {
 	query := `
 		UPDATE seats 
 		SET status = $1, updated_at = $2 
@@ -181,7 +182,7 @@ func (r *seatRepository) Update(seat *models.Seat) error {
 
 	seat.UpdatedAt = time.Now()
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, seat.Status, seat.UpdatedAt, seat.ID)
+	_, err := executor.ExecContext(ctx, query, seat.Status, seat.UpdatedAt, seat.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update seat: %w", err)
 	}
@@ -189,12 +190,12 @@ func (r *seatRepository) Update(seat *models.Seat) error {
 	return nil
 }
 
-func (r *seatRepository) ReserveSeats(seatIDs []int64, userID int) error {
+func (r *seatRepository) ReserveSeats(ctx context.Context, seatIDs []int64, userID int) error {
 	if len(seatIDs) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -205,7 +206,7 @@ func (r *seatRepository) ReserveSeats(seatIDs []int64, userID int) error {
 		SET status = $1, updated_at = $2 
 		WHERE id = ANY($3) AND status = $4`
 
-	result, err := tx.Exec(query, models.SeatStatusReserved, time.Now(), seatIDs, models.SeatStatusFree)
+	result, err := tx.ExecContext(ctx, query, models.SeatStatusReserved, time.Now(), seatIDs, models.SeatStatusFree)
 	if err != nil {
 		return fmt.Errorf("failed to reserve seats: %w", err)
 	}
@@ -222,7 +223,7 @@ func (r *seatRepository) ReserveSeats(seatIDs []int64, userID int) error {
 	return tx.Commit()
 }
 
-func (r *seatRepository) ReleaseSeats(seatIDs []int64) error {
+func (r *seatRepository) ReleaseSeats(ctx context.Context, seatIDs []int64) error {
 	if len(seatIDs) == 0 {
 		return nil
 	}
@@ -230,7 +231,7 @@ func (r *seatRepository) ReleaseSeats(seatIDs []int64) error {
 	query := `UPDATE seats SET status = $1, updated_at = $2 WHERE id = ANY($3)`
 
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, models.SeatStatusFree, time.Now(), seatIDs)
+	_, err := executor.ExecContext(ctx, query, models.SeatStatusFree, time.Now(), seatIDs)
 	if err != nil {
 		return fmt.Errorf("failed to release seats: %w", err)
 	}
@@ -238,7 +239,7 @@ func (r *seatRepository) ReleaseSeats(seatIDs []int64) error {
 	return nil
 }
 
-func (r *seatRepository) Save(s models.Seat) error {
+func (r *seatRepository) Save(ctx context.Context, s models.Seat) error {
 	query := `
 		INSERT INTO seats(event_id, row_number, seat_number, place_id, status, price, created_at, updated_at) 
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -249,7 +250,7 @@ func (r *seatRepository) Save(s models.Seat) error {
 	s.UpdatedAt = time.Now()
 
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, s.EventID, s.RowNumber, s.SeatNumber, s.PlaceId, s.Status, s.Price, s.CreatedAt, s.UpdatedAt).Scan(&s.ID)
+	err := executor.QueryRowContext(ctx, query, s.EventID, s.RowNumber, s.SeatNumber, s.PlaceId, s.Status, s.Price, s.CreatedAt, s.UpdatedAt).Scan(&s.ID)
 
 	if err != nil {
 		return fmt.Errorf("failed to save seat: %w", err)
@@ -257,11 +258,11 @@ func (r *seatRepository) Save(s models.Seat) error {
 	return nil
 }
 
-func (r *seatRepository) ResetAllStatus() error {
+func (r *seatRepository) ResetAllStatus(ctx context.Context) error {
 	query := `UPDATE seats SET status = $1, updated_at = $2`
 
 	executor := r.getExecutor()
-	_, err := executor.Exec(query, models.SeatStatusFree, time.Now())
+	_, err := executor.ExecContext(ctx, query, models.SeatStatusFree, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to reset all seats status: %w", err)
 	}
@@ -269,7 +270,7 @@ func (r *seatRepository) ResetAllStatus() error {
 	return nil
 }
 
-func (r *seatRepository) GetSeatStatistics(eventID int64) (map[string]int, string, error) {
+func (r *seatRepository) GetSeatStatistics(ctx context.Context, eventID int64) (map[string]int, string, error) {
 	// Запрос для получения статистики по местам
 	query := `
 		SELECT status, COUNT(*) as count 
@@ -278,7 +279,7 @@ func (r *seatRepository) GetSeatStatistics(eventID int64) (map[string]int, strin
 		GROUP BY status`
 
 	executor := r.getExecutor()
-	rows, err := executor.Query(query, eventID)
+	rows, err := executor.QueryContext(ctx, query, eventID)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to query seat statistics: %w", err)
 	}
@@ -308,7 +309,7 @@ func (r *seatRepository) GetSeatStatistics(eventID int64) (map[string]int, strin
 		WHERE event_id = $1 AND status = 'SOLD'`
 
 	var totalRevenue float64
-	err = executor.QueryRow(revenueQuery, eventID).Scan(&totalRevenue)
+	err = executor.QueryRowContext(ctx, revenueQuery, eventID).Scan(&totalRevenue)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to query total revenue: %w", err)
 	}
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
@@ -12,12 +14,22 @@ type Transaction struct {
 
 // TransactionRepository provides repository operations within a transaction
 type TransactionRepository struct {
-	tx          *sql.Tx
-	Event       EventRepository
-	Seat        SeatRepository
-	Booking     BookingRepository
-	BookingSeat BookingSeatRepository
-	User        UserRepository
+	tx             *sql.Tx
+	Event          EventRepository
+	Seat           SeatRepository
+	Booking        BookingRepository
+	BookingSeat    BookingSeatRepository
+	User           UserRepository
+	ProcessedEvent ProcessedEventRepository
+	Notification   NotificationRepository
+	BookingEvents  BookingEventLogRepository
+
+	afterCommit []func()
+}
+
+// AfterCommit registers fn to run only after the transaction commits successfully
+func (r *TransactionRepository) AfterCommit(fn func()) {
+	r.afterCommit = append(r.afterCommit, fn)
 }
 
 // TransactionFunc is a function that executes within a transaction
@@ -33,30 +45,36 @@ func NewTransactionManager(db *sql.DB) *TransactionManager {
 	return &TransactionManager{db: db}
 }
 
-// WithTransaction executes a function within a database transaction
+// WithTransaction executes a function within a database transaction bound to ctx
 // If the function returns an error, the transaction is rolled back
 // Otherwise, the transaction is committed
-func (tm *TransactionManager) WithTransaction(fn TransactionFunc) error {
-	tx, err := tm.db.Begin()
+// Cancellation of ctx aborts running queries and rolls the transaction back
+func (tm *TransactionManager) WithTransaction(ctx context.Context, fn TransactionFunc) error {
+	tx, err := tm.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	// Create repositories that use the transaction
 	txRepo := &TransactionRepository{
-		tx:          tx,
-		Event:       NewEventRepository(tm.db).WithTx(tx),
-		Seat:        NewSeatRepository(tm.db).WithTx(tx),
-		Booking:     NewBookingRepository(tm.db).WithTx(tx),
-		BookingSeat: NewBookingSeatRepository(tm.db).WithTx(tx),
-		User:        NewUserRepository(tm.db).WithTx(tx),
+		tx:             tx,
+		Event:          NewEventRepository(tm.db).WithTx(tx),
+		Seat:           NewSeatRepository(tm.db).WithTx(tx),
+		Booking:        NewBookingRepository(tm.db).WithTx(tx),
+		BookingSeat:    NewBookingSeatRepository(tm.db).WithTx(tx),
+		ProcessedEvent: NewProcessedEventRepository(tm.db).WithTx(tx),
+		Notification:   NewNotificationRepository(tm.db).WithTx(tx),
+		BookingEvents:  NewBookingEventLogRepository(tm.db).WithTx(tx),
 	}
+	// Кэш пользователей обновляется только после коммита
+	txRepo.User = newTxUserRepository(tm.db, tx, txRepo.AfterCommit)
 
 	// Execute the function
 	err = fn(txRepo)
 	if err != nil {
 		// Rollback on error
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		// При отмене ctx database/sql уже откатил транзакцию сам
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
 			return fmt.Errorf("transaction failed: %w, rollback failed: %v", err, rollbackErr)
 		}
 		return err
@@ -67,6 +85,10 @@ func (tm *TransactionManager) WithTransaction(fn TransactionFunc) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	for _, hook := range txRepo.afterCommit {
+		hook()
+	}
+
 	return nil
 }
 
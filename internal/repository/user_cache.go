@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUserCacheCapacity = 100_000
+	defaultUserCacheTTL      = 30 * time.Minute
+)
+
+// UserCache provides thread-safe bounded LRU caching for users.
+// Записи устаревают через ttl и перечитываются из БД при следующем обращении.
+type UserCache struct {
+	mu           sync.Mutex
+	capacity     int
+	ttl          time.Duration
+	entries      *list.List
+	usersByID    map[int]*list.Element
+	usersByEmail map[string]*list.Element
+	onChange     func(user *models.User)
+}
+
+type userCacheEntry struct {
+	user      *models.User
+	expiresAt time.Time
+}
+
+func NewUserCache(capacity int, ttl time.Duration) *UserCache {
+	return &UserCache{
+		capacity:     capacity,
+		ttl:          ttl,
+		entries:      list.New(),
+		usersByID:    make(map[int]*list.Element),
+		usersByEmail: make(map[string]*list.Element),
+	}
+}
+
+// ConfigureUserCache применяет настройки к общему кэшу пользователей
+func ConfigureUserCache(cfg config.UserCache) {
+	globalUserCache.Configure(cfg.Capacity, cfg.TTL)
+}
+
+// Configure меняет размер и время жизни записей, вытесняя лишние записи
+func (c *UserCache) Configure(capacity int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if capacity > 0 {
+		c.capacity = capacity
+	}
+	if ttl > 0 {
+		c.ttl = ttl
+	}
+	for c.entries.Len() > c.capacity {
+		c.removeElement(c.entries.Back())
+	}
+}
+
+// Capacity возвращает максимальное количество пользователей в кэше
+func (c *UserCache) Capacity() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capacity
+}
+
+func (c *UserCache) GetByID(userID int) *models.User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(c.usersByID[userID])
+}
+
+func (c *UserCache) GetByEmail(email string) *models.User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(c.usersByEmail[emailKey(email)])
+}
+
+func (c *UserCache) Set(user *models.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.usersByID[user.UserID]; ok {
+		c.removeElement(elem)
+	}
+	// Email мог принадлежать другой записи (например, устаревшей)
+	if elem, ok := c.usersByEmail[emailKey(user.Email)]; ok {
+		c.removeElement(elem)
+	}
+
+	elem := c.entries.PushFront(&userCacheEntry{user: user, expiresAt: time.Now().Add(c.ttl)})
+	c.usersByID[user.UserID] = elem
+	c.usersByEmail[emailKey(user.Email)] = elem
+
+	for c.entries.Len() > c.capacity {
+		c.removeElement(c.entries.Back())
+	}
+}
+
+// Invalidate удаляет пользователя из кэша, следующее обращение прочитает его из БД
+func (c *UserCache) Invalidate(userID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.usersByID[userID]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len возвращает текущее количество пользователей в кэше
+func (c *UserCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+// OnChange регистрирует обработчик изменений пользователей, сделанных этим инстансом
+func (c *UserCache) OnChange(fn func(user *models.User)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = fn
+}
+
+// notifyChanged сообщает об изменении пользователя другим инстансам
+func (c *UserCache) notifyChanged(user *models.User) {
+	c.mu.Lock()
+	fn := c.onChange
+	c.mu.Unlock()
+
+	if fn != nil {
+		fn(user)
+	}
+}
+
+func (c *UserCache) get(elem *list.Element) *models.User {
+	if elem == nil {
+		return nil
+	}
+
+	entry := elem.Value.(*userCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil
+	}
+
+	c.entries.MoveToFront(elem)
+	return entry.user
+}
+
+func (c *UserCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*userCacheEntry)
+	c.entries.Remove(elem)
+
+	if c.usersByID[entry.user.UserID] == elem {
+		delete(c.usersByID, entry.user.UserID)
+	}
+	if key := emailKey(entry.user.Email); c.usersByEmail[key] == elem {
+		delete(c.usersByEmail, key)
+	}
+}
+
+// emailKey email без учета регистра, как его сравнивает GetByEmail репозитория
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/pkg/cache"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// userInvalidationMessage сообщение об изменении пользователя, рассылаемое между инстансами
+type userInvalidationMessage struct {
+	UserID   int    `json:"user_id"`
+	Email    string `json:"email"`
+	Instance string `json:"instance"`
+}
+
+// StartUserCacheSync связывает кэши пользователей разных реплик через pub/sub:
+// изменения, сделанные этим инстансом, рассылаются в канал, а полученные от других
+// инстансов сообщения удаляют пользователя из локального кэша.
+// Синхронизация останавливается при отмене ctx.
+func (r *Repository) StartUserCacheSync(ctx context.Context, pubsub cache.Cache, channel string, logger *zap.Logger) error {
+	instanceID := uuid.New().String()
+
+	messages, err := pubsub.Subscribe(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to user invalidation channel: %w", err)
+	}
+
+	globalUserCache.OnChange(func(user *models.User) {
+		payload, err := json.Marshal(userInvalidationMessage{
+			UserID:   user.UserID,
+			Email:    user.Email,
+			Instance: instanceID,
+		})
+		if err != nil {
+			return
+		}
+
+		if err := pubsub.Publish(context.Background(), channel, string(payload)); err != nil {
+			logger.Warn("Failed to publish user invalidation",
+				zap.Int("user_id", user.UserID), zap.Error(err))
+		}
+	})
+
+	go func() {
+		defer globalUserCache.OnChange(nil)
+
+		for payload := range messages {
+			var msg userInvalidationMessage
+			if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+				logger.Warn("Invalid user invalidation message", zap.String("payload", payload), zap.Error(err))
+				continue
+			}
+
+			if msg.Instance == instanceID {
+				continue
+			}
+
+			globalUserCache.Invalidate(msg.UserID)
+			logger.Debug("User invalidated from cache", zap.Int("user_id", msg.UserID))
+		}
+	}()
+
+	logger.Info("User cache sync started", zap.String("channel", channel), zap.String("instance", instanceID))
+	return nil
+}
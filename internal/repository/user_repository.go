@@ -2,58 +2,45 @@ package repository
 
 import (
 	"biletter-service/internal/models"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"sync"
+	"time"
+
+	"github.com/lib/pq"
 )
 
-type UserRepository interface {
-	GetByID(userID int) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	PreloadCache() error
-	WithTx(tx *sql.Tx) UserRepository
-}
+// ErrDuplicateEmail пользователь с таким email уже есть (нарушение уникальности users.email)
+var ErrDuplicateEmail = errors.New("duplicate user email")
 
-// UserCache provides thread-safe caching for users
-type UserCache struct {
-	mu           sync.RWMutex
-	usersByID    map[int]*models.User
-	usersByEmail map[string]*models.User
-}
+// uniqueViolation код ошибки Postgres при нарушении ограничения уникальности
+const uniqueViolation = "23505"
 
-func NewUserCache() *UserCache {
-	return &UserCache{
-		usersByID:    make(map[int]*models.User),
-		usersByEmail: make(map[string]*models.User),
-	}
-}
-
-func (c *UserCache) GetByID(userID int) *models.User {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.usersByID[userID]
-}
+const userColumns = `user_id, email, password_hash, password_plain, first_name, surname,
+		birthday, registered_at, is_active, last_logged_in, role`
 
-func (c *UserCache) GetByEmail(email string) *models.User {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.usersByEmail[email]
-}
-
-func (c *UserCache) Set(user *models.User) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.usersByID[user.UserID] = user
-	c.usersByEmail[user.Email] = user
+type UserRepository interface {
+	GetByID(ctx context.Context, userID int) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) (*models.User, error)
+	UpdateProfile(ctx context.Context, user *models.User) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) (*models.User, error)
+	SetActive(ctx context.Context, userID int, active bool) (*models.User, error)
+	UpdateLastLoggedIn(ctx context.Context, userID int, loggedInAt time.Time) (*models.User, error)
+	PreloadCache(ctx context.Context) error
+	WithTx(tx *sql.Tx) UserRepository
 }
 
 type userRepository struct {
 	db    *sql.DB
 	tx    *sql.Tx
 	cache *UserCache
+	// afterCommit откладывает действие до коммита tx, задается TransactionManager
+	afterCommit func(func())
 }
 
-var globalUserCache = NewUserCache()
+var globalUserCache = NewUserCache(defaultUserCacheCapacity, defaultUserCacheTTL)
 
 func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{
@@ -66,10 +53,14 @@ func (r *userRepository) WithTx(tx *sql.Tx) UserRepository {
 	return &userRepository{db: r.db, tx: tx, cache: r.cache}
 }
 
+func newTxUserRepository(db *sql.DB, tx *sql.Tx, afterCommit func(func())) UserRepository {
+	return &userRepository{db: db, tx: tx, cache: globalUserCache, afterCommit: afterCommit}
+}
+
 func (r *userRepository) getExecutor() interface {
-	QueryRow(query string, args ...interface{}) *sql.Row
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 } {
 	if r.tx != nil {
 		return r.tx
@@ -77,7 +68,7 @@ func (r *userRepository) getExecutor() interface {
 	return r.db
 }
 
-func (r *userRepository) GetByID(userID int) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, userID int) (*models.User, error) {
 	// Проверяем кэш сначала
 	if cachedUser := r.cache.GetByID(userID); cachedUser != nil {
 		return cachedUser, nil
@@ -85,14 +76,14 @@ func (r *userRepository) GetByID(userID int) (*models.User, error) {
 
 	query := `
 		SELECT user_id, email, password_hash, password_plain, first_name, surname, 
-		birthday, registered_at, is_active, last_logged_in
+		birthday, registered_at, is_active, last_logged_in, role
 		FROM users WHERE user_id = $1`
 
 	var user models.User
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, userID).Scan(&user.UserID, &user.Email, &user.PasswordHash,
+	err := executor.QueryRowContext(ctx, query, userID).Scan(&user.UserID, &user.Email, &user.PasswordHash,
 		&user.PasswordPlain, &user.FirstName, &user.Surname, &user.Birthday,
-		&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn)
+		&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn, &user.Role)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -101,13 +92,17 @@ func (r *userRepository) GetByID(userID int) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 
-	// Сохраняем в кэш
-	r.cache.Set(&user)
+	// Сохраняем в кэш; прочитанное в транзакции может быть еще не закоммичено
+	if r.tx == nil {
+		r.cache.Set(&user)
+	}
 
 	return &user, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+// GetByEmail ищет пользователя по email без учета регистра: зарегистрированные через API
+// хранятся в нижнем регистре, в исходных данных регистр может быть любым
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	// Проверяем кэш сначала
 	if cachedUser := r.cache.GetByEmail(email); cachedUser != nil {
 		return cachedUser, nil
@@ -115,14 +110,14 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 
 	query := `
 		SELECT user_id, email, password_hash, password_plain, first_name, surname,
-		birthday, registered_at, is_active, last_logged_in
-		FROM users WHERE email = $1`
+		birthday, registered_at, is_active, last_logged_in, role
+		FROM users WHERE lower(email) = lower($1)`
 
 	var user models.User
 	executor := r.getExecutor()
-	err := executor.QueryRow(query, email).Scan(&user.UserID, &user.Email, &user.PasswordHash,
+	err := executor.QueryRowContext(ctx, query, email).Scan(&user.UserID, &user.Email, &user.PasswordHash,
 		&user.PasswordPlain, &user.FirstName, &user.Surname, &user.Birthday,
-		&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn)
+		&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn, &user.Role)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -131,20 +126,134 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 
-	// Сохраняем в кэш
-	r.cache.Set(&user)
+	// Сохраняем в кэш; прочитанное в транзакции может быть еще не закоммичено
+	if r.tx == nil {
+		r.cache.Set(&user)
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	query := `
+		INSERT INTO users (email, password_hash, password_plain, first_name, surname,
+		birthday, registered_at, is_active, last_logged_in)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + userColumns
+
+	now := time.Now()
+	executor := r.getExecutor()
+	created, err := scanUser(executor.QueryRowContext(ctx, query, user.Email, user.PasswordHash, user.PasswordPlain,
+		user.FirstName, user.Surname, user.Birthday, now, true, now))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil, ErrDuplicateEmail
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	r.cacheChanged(created)
+	return created, nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *models.User) (*models.User, error) {
+	query := `
+		UPDATE users SET first_name = $1, surname = $2, birthday = $3
+		WHERE user_id = $4
+		RETURNING ` + userColumns
+
+	return r.updateAndCache(ctx, query, "profile", user.FirstName, user.Surname, user.Birthday, user.UserID)
+}
+
+// UpdatePassword сохраняет новый хеш пароля и сбрасывает plaintext пароль из исходных данных
+func (r *userRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) (*models.User, error) {
+	query := `
+		UPDATE users SET password_hash = $1, password_plain = NULL
+		WHERE user_id = $2
+		RETURNING ` + userColumns
+
+	return r.updateAndCache(ctx, query, "password", passwordHash, userID)
+}
+
+func (r *userRepository) SetActive(ctx context.Context, userID int, active bool) (*models.User, error) {
+	query := `
+		UPDATE users SET is_active = $1
+		WHERE user_id = $2
+		RETURNING ` + userColumns
+
+	return r.updateAndCache(ctx, query, "active flag", active, userID)
+}
+
+func (r *userRepository) UpdateLastLoggedIn(ctx context.Context, userID int, loggedInAt time.Time) (*models.User, error) {
+	query := `
+		UPDATE users SET last_logged_in = $1
+		WHERE user_id = $2
+		RETURNING ` + userColumns
+
+	return r.updateAndCache(ctx, query, "last login", loggedInAt, userID)
+}
+
+// updateAndCache выполняет UPDATE ... RETURNING и заменяет запись в кэше свежей копией,
+// чтобы не изменять разделяемый объект пользователя на месте
+func (r *userRepository) updateAndCache(ctx context.Context, query, field string, args ...interface{}) (*models.User, error) {
+	executor := r.getExecutor()
+	user, err := scanUser(executor.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update user %s: %w", field, err)
+	}
+
+	r.cacheChanged(user)
+	return user, nil
+}
+
+// cacheChanged кладет измененного пользователя в кэш и оповещает другие инстансы.
+// В транзакции это откладывается до коммита, чтобы при откате в кэшах не осталось
+// незакоммиченных данных.
+func (r *userRepository) cacheChanged(user *models.User) {
+	update := func() {
+		r.cache.Set(user)
+		r.cache.notifyChanged(user)
+	}
+
+	switch {
+	case r.tx == nil:
+		update()
+	case r.afterCommit != nil:
+		r.afterCommit(update)
+	default:
+		// Транзакция открыта вызывающим, момент коммита неизвестен:
+		// убираем запись, следующее чтение после коммита пойдет в БД
+		r.cache.Invalidate(user.UserID)
+	}
+}
+
+func scanUser(row *sql.Row) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.UserID, &user.Email, &user.PasswordHash,
+		&user.PasswordPlain, &user.FirstName, &user.Surname, &user.Birthday,
+		&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn, &user.Role)
+	if err != nil {
+		return nil, err
+	}
 
 	return &user, nil
 }
 
-// PreloadCache загружает всех пользователей в кэш при старте приложения
-func (r *userRepository) PreloadCache() error {
+// PreloadCache загружает активных пользователей в кэш при старте приложения,
+// но не больше емкости кэша
+func (r *userRepository) PreloadCache(ctx context.Context) error {
 	query := `
 		SELECT user_id, email, password_hash, password_plain, first_name, surname,
-		birthday, registered_at, is_active, last_logged_in
-		FROM users WHERE is_active = true`
+		birthday, registered_at, is_active, last_logged_in, role
+		FROM users WHERE is_active = true
+		ORDER BY last_logged_in DESC
+		LIMIT $1`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, r.cache.Capacity())
 	if err != nil {
 		return fmt.Errorf("failed to preload users cache: %w", err)
 	}
@@ -155,7 +264,7 @@ func (r *userRepository) PreloadCache() error {
 		var user models.User
 		err := rows.Scan(&user.UserID, &user.Email, &user.PasswordHash,
 			&user.PasswordPlain, &user.FirstName, &user.Surname, &user.Birthday,
-			&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn)
+			&user.RegisteredAt, &user.IsActive, &user.LastLoggedIn, &user.Role)
 		if err != nil {
 			return fmt.Errorf("failed to scan user during cache preload: %w", err)
 		}
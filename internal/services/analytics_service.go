@@ -2,46 +2,52 @@ package services
 
 import (
 	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
 	"biletter-service/internal/repository"
+	"biletter-service/pkg/cache"
+	"context"
 	"strconv"
 
 	"go.uber.org/zap"
 )
 
 type AnalyticsService interface {
-	GetAnalytics(eventID int64) (*models.AnalyticsResponse, error)
+	GetAnalytics(ctx context.Context, eventID int64) (*models.AnalyticsResponse, error)
 }
 
 type analyticsService struct {
 	seatRepo    repository.SeatRepository
 	bookingRepo repository.BookingRepository
+	cacheClient cache.Cache
 	logger      *zap.Logger
 }
 
 func NewAnalyticsService(
 	seatRepo repository.SeatRepository,
 	bookingRepo repository.BookingRepository,
+	cacheClient cache.Cache,
 	logger *zap.Logger,
 ) AnalyticsService {
 	return &analyticsService{
 		seatRepo:    seatRepo,
 		bookingRepo: bookingRepo,
+		cacheClient: cacheClient,
 		logger:      logger,
 	}
 }
 
-func (s *analyticsService) GetAnalytics(eventID int64) (*models.AnalyticsResponse, error) {
+func (s *analyticsService) GetAnalytics(ctx context.Context, eventID int64) (*models.AnalyticsResponse, error) {
 	s.logger.Info("Getting analytics for event", zap.Int64("event_id", eventID))
 
 	// Получаем статистику по местам и выручку
-	seatStats, revenue, err := s.seatRepo.GetSeatStatistics(eventID)
+	seatStats, revenue, err := s.seatRepo.GetSeatStatistics(ctx, eventID)
 	if err != nil {
 		s.logger.Error("Failed to get seat statistics", zap.Error(err))
 		return nil, err
 	}
 
 	// Получаем количество броней
-	bookingsCount, _, err := s.bookingRepo.GetBookingStatistics(eventID)
+	bookingsCount, _, err := s.bookingRepo.GetBookingStatistics(ctx, eventID)
 	if err != nil {
 		s.logger.Error("Failed to get booking statistics", zap.Error(err))
 		return nil, err
@@ -63,8 +69,33 @@ func (s *analyticsService) GetAnalytics(eventID int64) (*models.AnalyticsRespons
 		FreeSeats:     freeSeats,
 		TotalRevenue:  revenue,
 		BookingsCount: bookingsCount,
+		EventStream:   s.eventStreamStats(ctx, eventID),
 	}
 
 	s.logger.Info("Analytics retrieved successfully", zap.Int64("event_id", eventID))
 	return response, nil
 }
+
+// eventStreamStats читает счетчики доменных событий мероприятия из read model.
+// Места и брони выше считаются по базе, поэтому без Redis ответ отдается без счетчиков.
+func (s *analyticsService) eventStreamStats(ctx context.Context, eventID int64) *models.EventStreamStats {
+	if s.cacheClient == nil {
+		return nil
+	}
+
+	stats, ok, err := readmodel.EventStats(ctx, s.cacheClient, eventID)
+	if err != nil {
+		s.logger.Warn("Failed to get event stream stats", zap.Int64("event_id", eventID), zap.Error(err))
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	return &models.EventStreamStats{
+		BookingsCreated:   stats[readmodel.StatBookingsCreated],
+		BookingsCancelled: stats[readmodel.StatBookingsCancelled],
+		SeatsSelected:     stats[readmodel.StatSeatsSelected],
+		SeatsReleased:     stats[readmodel.StatSeatsReleased],
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"biletter-service/pkg/cache"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	revokedTokenKeyPrefix = "auth:revoked:"
+)
+
+// ErrTokenRevoked токен отозван или refresh токен уже обменян
+var ErrTokenRevoked = errors.New("invalid token: token has been revoked")
+
+// TokenClaims содержимое подписанного токена
+type TokenClaims struct {
+	UserID    int    `json:"uid"`
+	Email     string `json:"email"`
+	TokenType string `json:"typ"`
+	// Отпечаток пароля на момент выдачи: после смены пароля токен перестает приниматься
+	PasswordFingerprint string `json:"pwf"`
+	jwt.RegisteredClaims
+}
+
+type AuthService interface {
+	Login(ctx context.Context, email, password string) (*models.TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error)
+	Revoke(ctx context.Context, token string) error
+	ValidateAccessToken(ctx context.Context, token string) (*TokenClaims, *models.User, error)
+}
+
+type authService struct {
+	userService UserService
+	cacheClient cache.Cache
+	config      config.Auth
+}
+
+func NewAuthService(userService UserService, cacheClient cache.Cache, cfg config.Auth) AuthService {
+	return &authService{
+		userService: userService,
+		cacheClient: cacheClient,
+		config:      cfg,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, email, password string) (*models.TokenResponse, error) {
+	user, err := s.userService.ValidateCredentials(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userService.RecordLogin(ctx, user.UserID); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	claims, user, err := s.parseToken(ctx, refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ротация: использованный refresh токен больше не принимается. Отметка ставится
+	// атомарно, поэтому из параллельных запросов с одним токеном проходит только один.
+	if err := s.claimRefreshToken(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *authService) Revoke(ctx context.Context, token string) error {
+	claims, err := s.parseClaims(token)
+	if err != nil {
+		return err
+	}
+
+	return s.revokeClaims(ctx, claims)
+}
+
+// ValidateAccessToken проверяет access токен и возвращает его claims и актуального пользователя
+func (s *authService) ValidateAccessToken(ctx context.Context, token string) (*TokenClaims, *models.User, error) {
+	return s.parseToken(ctx, token, TokenTypeAccess)
+}
+
+func (s *authService) issueTokens(user *models.User) (*models.TokenResponse, error) {
+	accessToken, err := s.signToken(user, TokenTypeAccess, s.config.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.signToken(user, TokenTypeRefresh, s.config.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *authService) signToken(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := TokenClaims{
+		UserID:              user.UserID,
+		Email:               user.Email,
+		TokenType:           tokenType,
+		PasswordFingerprint: s.passwordFingerprint(user),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    s.config.Issuer,
+			Subject:   fmt.Sprintf("%d", user.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.JWTSecret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, nil
+}
+
+// parseClaims проверяет подпись и срок действия токена
+func (s *authService) parseClaims(token string) (*TokenClaims, error) {
+	var claims TokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.config.JWTSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.config.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return &claims, nil
+}
+
+// parseToken дополнительно проверяет тип токена, отсутствие его в списке отзыва и то,
+// что пользователь активен и не менял пароль после выдачи токена. Пользователь читается
+// через кэш пользователей, который синхронизируется между репликами при изменениях.
+func (s *authService) parseToken(ctx context.Context, token, tokenType string) (*TokenClaims, *models.User, error) {
+	claims, err := s.parseClaims(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if claims.TokenType != tokenType {
+		return nil, nil, fmt.Errorf("invalid token: unexpected token type %q", claims.TokenType)
+	}
+
+	revoked, err := s.isRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, ErrTokenRevoked
+	}
+
+	user, err := s.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, nil, errors.New("invalid token: user is not active")
+	}
+	if !hmac.Equal([]byte(claims.PasswordFingerprint), []byte(s.passwordFingerprint(user))) {
+		return nil, nil, errors.New("invalid token: password has been changed")
+	}
+
+	return claims, user, nil
+}
+
+// passwordFingerprint HMAC текущего пароля пользователя на секрете подписи.
+// По отпечатку нельзя восстановить хеш пароля, но он меняется вместе с паролем.
+func (s *authService) passwordFingerprint(user *models.User) string {
+	mac := hmac.New(sha256.New, []byte(s.config.JWTSecret))
+	if user.PasswordPlain != nil {
+		mac.Write([]byte(*user.PasswordPlain))
+	} else {
+		mac.Write([]byte(user.PasswordHash))
+	}
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// revokeClaims помещает jti в список отзыва до истечения срока действия токена
+func (s *authService) revokeClaims(ctx context.Context, claims *TokenClaims) error {
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.cacheClient.Set(ctx, revokedTokenKeyPrefix+claims.ID, 1, ttl); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// claimRefreshToken атомарно помещает jti в список отзыва. Если jti уже там,
+// токен использован или отозван другим запросом.
+func (s *authService) claimRefreshToken(ctx context.Context, claims *TokenClaims) error {
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return errors.New("invalid token: token is expired")
+	}
+
+	claimed, err := s.cacheClient.SetNX(ctx, revokedTokenKeyPrefix+claims.ID, 1, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	if !claimed {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+func (s *authService) isRevoked(ctx context.Context, tokenID string) (bool, error) {
+	count, err := s.cacheClient.Exists(ctx, revokedTokenKeyPrefix+tokenID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"biletter-service/internal/eventschema"
+	"biletter-service/internal/models"
+	"biletter-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ErrBookingHistoryNotFound по брони нет ни событий в журнале, ни записи в базе
+var ErrBookingHistoryNotFound = errors.New("booking history not found")
+
+type BookingHistoryService interface {
+	GetHistory(ctx context.Context, bookingID int64) (*models.BookingHistoryResponse, error)
+}
+
+type bookingHistoryService struct {
+	eventLogRepo repository.BookingEventLogRepository
+	bookingRepo  repository.BookingRepository
+	logger       *zap.Logger
+}
+
+func NewBookingHistoryService(
+	eventLogRepo repository.BookingEventLogRepository,
+	bookingRepo repository.BookingRepository,
+	logger *zap.Logger,
+) BookingHistoryService {
+	return &bookingHistoryService{
+		eventLogRepo: eventLogRepo,
+		bookingRepo:  bookingRepo,
+		logger:       logger,
+	}
+}
+
+// GetHistory восстанавливает историю брони из журнала событий booking_event_log.
+// Состояние (статус, места, платеж) получается последовательным применением событий,
+// а не чтением текущей строки bookings.
+func (s *bookingHistoryService) GetHistory(ctx context.Context, bookingID int64) (*models.BookingHistoryResponse, error) {
+	entries, err := s.eventLogRepo.GetByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		// Бронь могла быть создана до появления журнала или события еще не обработаны
+		booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if booking == nil {
+			return nil, ErrBookingHistoryNotFound
+		}
+	}
+
+	history := &models.BookingHistoryResponse{
+		BookingID: bookingID,
+		Seats:     []int64{},
+		Timeline:  make([]models.BookingHistoryTimeItem, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		item, err := s.apply(history, entry)
+		if err != nil {
+			// Событие с неизвестной схемой не должно скрывать остальную историю
+			s.logger.Warn("Failed to decode booking event",
+				zap.Int64("booking_id", bookingID),
+				zap.String("event_id", entry.EventID),
+				zap.Error(err))
+			item = models.BookingHistoryTimeItem{Description: "undecodable event", Data: entry.Data}
+		}
+
+		item.EventID = entry.EventID
+		item.Type = entry.EventType
+		item.Version = entry.EventVersion
+		item.OccurredAt = entry.OccurredAt
+		history.Timeline = append(history.Timeline, item)
+	}
+
+	return history, nil
+}
+
+// apply применяет событие к состоянию истории и возвращает элемент ленты с данными последней версии схемы
+func (s *bookingHistoryService) apply(history *models.BookingHistoryResponse, entry models.BookingEventLogEntry) (models.BookingHistoryTimeItem, error) {
+	event := &models.DomainEvent{
+		ID:          entry.EventID,
+		Type:        entry.EventType,
+		Version:     entry.EventVersion,
+		AggregateID: fmt.Sprint(entry.BookingID),
+		Data:        entry.Data,
+		Timestamp:   entry.OccurredAt,
+	}
+
+	switch entry.EventType {
+	case models.BookingCreatedEvent:
+		var data models.BookingCreatedData
+		if err := eventschema.Default.Decode(event, &data); err != nil {
+			return models.BookingHistoryTimeItem{}, err
+		}
+		history.EventID = data.EventID
+		history.UserID = data.UserID
+		history.Status = models.BookingStatusPending
+		return models.BookingHistoryTimeItem{Description: "booking created", Data: data}, nil
+
+	case models.SeatSelectedEvent:
+		var data models.SeatSelectedData
+		if err := eventschema.Default.Decode(event, &data); err != nil {
+			return models.BookingHistoryTimeItem{}, err
+		}
+		history.Seats = append(removeSeat(history.Seats, data.SeatID), data.SeatID)
+		return models.BookingHistoryTimeItem{Description: fmt.Sprintf("seat %d selected", data.SeatID), Data: data}, nil
+
+	case models.SeatReleasedEvent:
+		var data models.SeatReleasedData
+		if err := eventschema.Default.Decode(event, &data); err != nil {
+			return models.BookingHistoryTimeItem{}, err
+		}
+		history.Seats = removeSeat(history.Seats, data.SeatID)
+		return models.BookingHistoryTimeItem{Description: fmt.Sprintf("seat %d released", data.SeatID), Data: data}, nil
+
+	case models.PaymentUpdatedEvent:
+		var data models.PaymentUpdatedData
+		if err := eventschema.Default.Decode(event, &data); err != nil {
+			return models.BookingHistoryTimeItem{}, err
+		}
+		history.Status = data.Status
+		if data.PaymentID != "" {
+			history.PaymentID = data.PaymentID
+		}
+		description := fmt.Sprintf("payment updated: booking %s", data.Status)
+		if data.PaymentStatus != "" {
+			description += fmt.Sprintf(", payment %s", data.PaymentStatus)
+		}
+		return models.BookingHistoryTimeItem{Description: description, Data: data}, nil
+
+	case models.BookingCancelledEvent:
+		var data models.BookingCancelledData
+		if err := eventschema.Default.Decode(event, &data); err != nil {
+			return models.BookingHistoryTimeItem{}, err
+		}
+		history.Status = models.BookingStatusCancelled
+		// При отмене места брони освобождаются
+		history.Seats = []int64{}
+		return models.BookingHistoryTimeItem{Description: "booking cancelled", Data: data}, nil
+
+	default:
+		return models.BookingHistoryTimeItem{Description: "unknown event", Data: entry.Data}, nil
+	}
+}
+
+// removeSeat убирает место из списка мест брони, сохраняя порядок остальных
+func removeSeat(seats []int64, seatID int64) []int64 {
+	result := seats[:0]
+	for _, id := range seats {
+		if id != seatID {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -1,23 +1,42 @@
 package services
 
 import (
+	"biletter-service/internal/eventschema"
 	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
 	"biletter-service/internal/repository"
 	"biletter-service/pkg/broker"
+	"biletter-service/pkg/cache"
+	"biletter-service/pkg/logger"
+	"biletter-service/pkg/metrics"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Бизнес-отказы операций с бронями; тексты возвращаются клиентам API
+var (
+	ErrBookingNotFound         = errors.New("booking not found")
+	ErrNotBookingOwner         = errors.New("unauthorized: booking belongs to another user")
+	ErrBookingCancelled        = errors.New("booking is cancelled")
+	ErrBookingAlreadyCancelled = errors.New("booking already cancelled")
+	ErrSeatNotFound            = errors.New("seat not found")
+	ErrSeatNotAvailable        = errors.New("seat is not available")
+	ErrSeatNotReserved         = errors.New("seat is not reserved")
 )
 
 type BookingService interface {
-	CreateBooking(req *models.CreateBookingRequest, userID int) (*models.CreateBookingResponse, error)
-	GetBookingsByUser(userID int) ([]models.ListBookingsResponseItem, error)
-	CancelBooking(req *models.CancelBookingRequest, userID int) error
-	SelectSeat(bookingID, seatID int64, userID int) error
-	ReleaseSeat(seatID int64, userID int) error
+	CreateBooking(ctx context.Context, req *models.CreateBookingRequest, userID int) (*models.CreateBookingResponse, error)
+	GetBookingsByUser(ctx context.Context, userID int) ([]models.ListBookingsResponseItem, error)
+	CancelBooking(ctx context.Context, req *models.CancelBookingRequest, userID int) error
+	SelectSeat(ctx context.Context, bookingID, seatID int64, userID int) error
+	ReleaseSeat(ctx context.Context, seatID int64, userID int) error
 }
 
 type bookingService struct {
@@ -26,23 +45,25 @@ type bookingService struct {
 	seatRepo        repository.SeatRepository
 	eventRepo       repository.EventRepository
 	txManager       *repository.TransactionManager
+	cacheClient     cache.Cache
 	eventPublisher  broker.Publisher
 	bookingTopic    string
 }
 
-func NewBookingService(bookingRepo repository.BookingRepository, bookingSeatRepo repository.BookingSeatRepository, seatRepo repository.SeatRepository, eventRepo repository.EventRepository, txManager *repository.TransactionManager, eventPublisher broker.Publisher, bookingTopic string) BookingService {
+func NewBookingService(bookingRepo repository.BookingRepository, bookingSeatRepo repository.BookingSeatRepository, seatRepo repository.SeatRepository, eventRepo repository.EventRepository, txManager *repository.TransactionManager, cacheClient cache.Cache, eventPublisher broker.Publisher, bookingTopic string) BookingService {
 	return &bookingService{
 		bookingRepo:     bookingRepo,
 		bookingSeatRepo: bookingSeatRepo,
 		seatRepo:        seatRepo,
 		eventRepo:       eventRepo,
 		txManager:       txManager,
+		cacheClient:     cacheClient,
 		eventPublisher:  eventPublisher,
 		bookingTopic:    bookingTopic,
 	}
 }
 
-func (s *bookingService) CreateBooking(req *models.CreateBookingRequest, userID int) (*models.CreateBookingResponse, error) {
+func (s *bookingService) CreateBooking(ctx context.Context, req *models.CreateBookingRequest, userID int) (*models.CreateBookingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -53,8 +74,8 @@ func (s *bookingService) CreateBooking(req *models.CreateBookingRequest, userID
 	// Сохраняем для отправки событии
 	var createdBooking *models.Booking
 
-	err := s.txManager.WithTransaction(func(txRepo *repository.TransactionRepository) error {
-		event, err := s.eventRepo.GetByID(req.EventID)
+	err := s.txManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
+		event, err := s.eventRepo.GetByID(ctx, req.EventID)
 		if err != nil {
 			return fmt.Errorf("failed to get event: %w", err)
 		}
@@ -71,7 +92,7 @@ func (s *bookingService) CreateBooking(req *models.CreateBookingRequest, userID
 			OrderID:     &orderID,
 		}
 
-		created, err := s.bookingRepo.Create(booking)
+		created, err := s.bookingRepo.Create(ctx, booking)
 		if err != nil {
 			return fmt.Errorf("failed to create booking: %w", err)
 		}
@@ -84,6 +105,8 @@ func (s *bookingService) CreateBooking(req *models.CreateBookingRequest, userID
 		return nil, err
 	}
 
+	metrics.Bookings.WithLabelValues(metrics.BookingCreated).Inc()
+
 	// Отправляем событие создания брони
 	eventData := models.BookingCreatedData{
 		BookingID:   createdBooking.ID,
@@ -91,15 +114,15 @@ func (s *bookingService) CreateBooking(req *models.CreateBookingRequest, userID
 		UserID:      createdBooking.UserID,
 		TotalAmount: createdBooking.TotalAmount.Mul(decimal.NewFromInt(100)).IntPart(),
 	}
-	s.publishEvent(models.BookingCreatedEvent, createdBooking.ID, eventData)
+	s.publishEvent(ctx, models.BookingCreatedEvent, createdBooking.ID, eventData)
 
 	return &models.CreateBookingResponse{
 		ID: createdBooking.ID,
 	}, nil
 }
 
-func (s *bookingService) GetBookingsByUser(userID int) ([]models.ListBookingsResponseItem, error) {
-	bookings, err := s.bookingRepo.GetByUserID(userID)
+func (s *bookingService) GetBookingsByUser(ctx context.Context, userID int) ([]models.ListBookingsResponseItem, error) {
+	bookings, err := s.bookingRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookings: %w", err)
 	}
@@ -110,7 +133,7 @@ func (s *bookingService) GetBookingsByUser(userID int) ([]models.ListBookingsRes
 	}
 
 	// Получаем разом места по всем броням
-	bookingSeats, err := s.bookingSeatRepo.GetByBookingIDs(bookingIDs)
+	bookingSeats, err := s.bookingSeatRepo.GetByBookingIDs(ctx, bookingIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get booking seats: %w", err)
 	}
@@ -137,7 +160,7 @@ func (s *bookingService) GetBookingsByUser(userID int) ([]models.ListBookingsRes
 	return response, nil
 }
 
-func (s *bookingService) CancelBooking(req *models.CancelBookingRequest, userID int) error {
+func (s *bookingService) CancelBooking(ctx context.Context, req *models.CancelBookingRequest, userID int) error {
 	if req == nil {
 		return fmt.Errorf("request cannot be nil")
 	}
@@ -147,50 +170,50 @@ func (s *bookingService) CancelBooking(req *models.CancelBookingRequest, userID
 
 	// Сохраняем для отправки событии
 	var removedBookingSeats []*models.BookingSeat
+	var eventID int64
 
-	err := s.txManager.WithTransaction(func(txRepo *repository.TransactionRepository) error {
-		booking, err := txRepo.Booking.GetByIDForUpdate(req.BookingID)
+	err := s.txManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
+		booking, err := txRepo.Booking.GetByIDForUpdate(ctx, req.BookingID)
 		if err != nil {
 			return fmt.Errorf("failed to get booking: %w", err)
 		}
 
 		if booking == nil {
-			return fmt.Errorf("booking not found")
+			return ErrBookingNotFound
 		}
 
 		// Проверяем, что пользователь является владельцем брони
 		if booking.UserID != userID {
-			return fmt.Errorf("unauthorized: booking belongs to another user")
+			return ErrNotBookingOwner
 		}
 
 		if booking.Status == models.BookingStatusCancelled {
-			return fmt.Errorf("booking already cancelled")
+			return ErrBookingAlreadyCancelled
 		}
 
-		booking.Status = models.BookingStatusCancelled
-		err = txRepo.Booking.Update(booking)
+		removedBookingSeats, err = releaseAllBookingSeats(ctx, txRepo, booking)
 		if err != nil {
-			return fmt.Errorf("failed to update booking: %w", err)
+			return err
 		}
 
-		// Получаем места
-		bookingSeats, err := txRepo.BookingSeat.GetByBookingID(booking.ID)
+		booking.Status = models.BookingStatusCancelled
+		err = txRepo.Booking.Update(ctx, booking)
 		if err != nil {
-			return fmt.Errorf("failed to get booking seats: %w", err)
-		}
-		removedBookingSeats = bookingSeats
-
-		for _, bookingSeat := range bookingSeats {
-			err = txRepo.BookingSeat.Delete(bookingSeat.ID)
-			if err != nil {
-				return fmt.Errorf("failed to delete booking seat: %w", err)
-			}
+			return fmt.Errorf("failed to update booking: %w", err)
 		}
 
+		eventID = booking.EventID
 		return nil
 	})
 
 	if err == nil {
+		if len(removedBookingSeats) > 0 {
+			invalidateSeats(ctx, s.cacheClient, eventID)
+		}
+
+		metrics.Bookings.WithLabelValues(metrics.BookingCancelled).Inc()
+		metrics.Seats.WithLabelValues(metrics.SeatReleased).Add(float64(len(removedBookingSeats)))
+
 		if removedBookingSeats != nil && len(removedBookingSeats) > 0 {
 			for _, bookingSeat := range removedBookingSeats {
 				// Отправляем событие освобождения места
@@ -199,7 +222,7 @@ func (s *bookingService) CancelBooking(req *models.CancelBookingRequest, userID
 					SeatID:    bookingSeat.SeatID,
 					UserID:    userID,
 				}
-				s.publishEvent(models.SeatReleasedEvent, req.BookingID, eventData)
+				s.publishEvent(ctx, models.SeatReleasedEvent, req.BookingID, eventData)
 			}
 		}
 
@@ -209,122 +232,140 @@ func (s *bookingService) CancelBooking(req *models.CancelBookingRequest, userID
 			UserID:    userID,
 			Reason:    "cancelled_by_user",
 		}
-		s.publishEvent(models.BookingCancelledEvent, req.BookingID, eventData)
+		s.publishEvent(ctx, models.BookingCancelledEvent, req.BookingID, eventData)
 	}
 
 	return err
 }
 
-func (s *bookingService) SelectSeat(bookingID, seatID int64, userID int) error {
-	err := s.txManager.WithTransaction(func(txRepo *repository.TransactionRepository) error {
-		// Используем пессимистичную блокировку для места
-		seat, err := txRepo.Seat.GetByIDForUpdate(seatID)
+func (s *bookingService) SelectSeat(ctx context.Context, bookingID, seatID int64, userID int) error {
+	var eventID int64
+
+	err := s.txManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
+		// Бронь блокируется раньше места, в том же порядке, что и при отмене брони:
+		// иначе место могло бы попасть в бронь, которую параллельно отменяют
+		booking, err := txRepo.Booking.GetByIDForUpdate(ctx, bookingID)
 		if err != nil {
-			return fmt.Errorf("failed to get seat for update: %w", err)
+			return fmt.Errorf("failed to get booking for update: %w", err)
 		}
-		if seat == nil {
-			return fmt.Errorf("seat not found")
+		if booking == nil {
+			return ErrBookingNotFound
 		}
 
-		// Проверяем, что место свободно
-		if seat.Status != models.SeatStatusFree {
-			return fmt.Errorf("seat is not available")
+		// Проверяем, что пользователь является владельцем брони
+		if booking.UserID != userID {
+			return ErrNotBookingOwner
+		}
+
+		if booking.Status == models.BookingStatusCancelled {
+			return ErrBookingCancelled
 		}
 
-		// Получаем бронь
-		booking, err := txRepo.Booking.GetByID(bookingID)
+		// Используем пессимистичную блокировку для места
+		seat, err := txRepo.Seat.GetByIDForUpdate(ctx, seatID)
 		if err != nil {
-			return fmt.Errorf("failed to get booking: %w", err)
+			return fmt.Errorf("failed to get seat for update: %w", err)
 		}
-		if booking == nil {
-			return fmt.Errorf("booking not found")
+		if seat == nil {
+			return ErrSeatNotFound
 		}
 
-		// Проверяем, что пользователь является владельцем брони
-		if booking.UserID != userID {
-			return fmt.Errorf("unauthorized: booking belongs to another user")
+		// Проверяем, что место свободно
+		if seat.Status != models.SeatStatusFree {
+			return ErrSeatNotAvailable
 		}
 
 		// Резервируем место
 		seat.Status = models.SeatStatusReserved
-		err = txRepo.Seat.Update(seat)
+		err = txRepo.Seat.Update(ctx, seat)
 		if err != nil {
 			return fmt.Errorf("failed to reserve seat: %w", err)
 		}
 
 		// Создаем связь брони с местом
 		bookingSeat := &models.BookingSeat{BookingID: bookingID, SeatID: seatID}
-		_, err = txRepo.BookingSeat.Create(bookingSeat)
+		_, err = txRepo.BookingSeat.Create(ctx, bookingSeat)
 		if err != nil {
 			return fmt.Errorf("failed to create booking seat: %w", err)
 		}
 
+		// Стоимость брони - сумма цен выбранных мест, ее отправляет в шлюз InitiatePayment
+		booking.TotalAmount = booking.TotalAmount.Add(seat.Price)
+		if err := txRepo.Booking.Update(ctx, booking); err != nil {
+			return fmt.Errorf("failed to update booking amount: %w", err)
+		}
+
+		eventID = seat.EventID
 		return nil
 	})
 
 	// Отправляем событие выбора места после успешного завершения транзакции
 	if err == nil {
+		invalidateSeats(ctx, s.cacheClient, eventID)
+		metrics.Seats.WithLabelValues(metrics.SeatSelected).Inc()
 		eventData := models.SeatSelectedData{
 			BookingID: bookingID,
 			SeatID:    seatID,
 			UserID:    userID,
 		}
-		s.publishEvent(models.SeatSelectedEvent, bookingID, eventData)
+		s.publishEvent(ctx, models.SeatSelectedEvent, bookingID, eventData)
 	}
 
 	return err
 }
 
-func (s *bookingService) ReleaseSeat(seatID int64, userID int) error {
+func (s *bookingService) ReleaseSeat(ctx context.Context, seatID int64, userID int) error {
 	// Сохраняем для отправки события
-	var releasedBookingID int64
+	var releasedBookingID, eventID int64
 
-	err := s.txManager.WithTransaction(func(txRepo *repository.TransactionRepository) error {
-		// Получаем мести с пессимистичной блокировкой
-		seat, err := txRepo.Seat.GetByIDForUpdate(seatID)
+	err := s.txManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
+		// Бронь места читается без блокировки: блокировки берутся в порядке бронь -> место,
+		// как при выборе места и отмене брони, а связь перепроверяется под блокировкой места
+		bookingSeats, err := txRepo.BookingSeat.GetBySeatID(ctx, seatID)
 		if err != nil {
-			return fmt.Errorf("failed to get seat: %w", err)
-		}
-		if seat == nil {
-			return fmt.Errorf("seat not found")
+			return fmt.Errorf("failed to get booking seats: %w", err)
 		}
-		if seat.Status != models.SeatStatusReserved {
-			return fmt.Errorf("seat is not reserved")
+
+		if len(bookingSeats) == 0 {
+			eventID, err = releaseOrphanSeat(ctx, txRepo, seatID)
+			return err
 		}
 
-		// Получаем связи места с бронью до удаления
-		bookingSeats, err := txRepo.BookingSeat.GetBySeatID(seatID)
+		booking, err := txRepo.Booking.GetByIDForUpdate(ctx, bookingSeats[0].BookingID)
 		if err != nil {
-			return fmt.Errorf("failed to get booking seats: %w", err)
+			return fmt.Errorf("failed to get booking for update: %w", err)
 		}
-
-		if len(bookingSeats) > 0 {
-			releasedBookingID = bookingSeats[0].BookingID
-			booking, err := txRepo.Booking.GetByID(releasedBookingID)
-			if err != nil {
-				return fmt.Errorf("failed to get booking: %w", err)
-			}
-			if booking.UserID != userID {
-				return fmt.Errorf("unauthorized: booking belongs to another user")
-			}
+		if booking == nil {
+			return ErrBookingNotFound
+		}
+		if booking.UserID != userID {
+			return ErrNotBookingOwner
 		}
 
-		err = txRepo.Seat.UpdateStatus(seatID, models.SeatStatusFree)
+		seat, err := releaseBookingSeat(ctx, txRepo, booking.ID, seatID)
 		if err != nil {
-			return fmt.Errorf("failed to release seat: %w", err)
+			return err
+		}
+		// Место успели освободить или отдать другой брони
+		if seat == nil || seat.Status != models.SeatStatusReserved {
+			return ErrSeatNotReserved
 		}
 
-		// Удаляем связь места с бронью и сохраняем ID брони для события
-		for _, bookingSeat := range bookingSeats {
-			err = txRepo.BookingSeat.Delete(bookingSeat.ID)
-			if err != nil {
-				return fmt.Errorf("failed to delete booking seat: %w", err)
-			}
+		booking.TotalAmount = booking.TotalAmount.Sub(seat.Price)
+		if err := txRepo.Booking.Update(ctx, booking); err != nil {
+			return fmt.Errorf("failed to update booking amount: %w", err)
 		}
 
+		releasedBookingID = booking.ID
+		eventID = seat.EventID
 		return nil
 	})
 
+	if err == nil {
+		invalidateSeats(ctx, s.cacheClient, eventID)
+		metrics.Seats.WithLabelValues(metrics.SeatReleased).Inc()
+	}
+
 	// Отправляем событие освобождения места после успешного завершения транзакции
 	if err == nil && releasedBookingID > 0 {
 		eventData := models.SeatReleasedData{
@@ -332,24 +373,145 @@ func (s *bookingService) ReleaseSeat(seatID int64, userID int) error {
 			SeatID:    seatID,
 			UserID:    userID,
 		}
-		s.publishEvent(models.SeatReleasedEvent, releasedBookingID, eventData)
+		s.publishEvent(ctx, models.SeatReleasedEvent, releasedBookingID, eventData)
 	}
 
 	return err
 }
 
+// releaseAllBookingSeats освобождает все места отменяемой брони, обнуляет ее сумму
+// и возвращает связи освобожденных мест. Бронь должна быть заблокирована вызывающим.
+func releaseAllBookingSeats(ctx context.Context, txRepo *repository.TransactionRepository, booking *models.Booking) ([]*models.BookingSeat, error) {
+	bookingSeats, err := txRepo.BookingSeat.GetByBookingID(ctx, booking.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking seats: %w", err)
+	}
+
+	// Места блокируются в порядке ID, чтобы параллельные отмены не ждали друг друга по кругу
+	sort.Slice(bookingSeats, func(i, j int) bool { return bookingSeats[i].SeatID < bookingSeats[j].SeatID })
+
+	var released []*models.BookingSeat
+	for _, bookingSeat := range bookingSeats {
+		seat, err := releaseBookingSeat(ctx, txRepo, booking.ID, bookingSeat.SeatID)
+		if err != nil {
+			return nil, err
+		}
+		if seat != nil {
+			released = append(released, bookingSeat)
+		}
+	}
+
+	// У отмененной брони не остается мест, платить не за что
+	booking.TotalAmount = decimal.Zero
+	return released, nil
+}
+
+// releaseBookingSeat освобождает место брони под блокировкой места и возвращает место
+// в состоянии до освобождения, либо nil, если место уже не принадлежит брони. Связь
+// перечитывается после блокировки: пока место не было заблокировано, его могли освободить
+// и отдать другой брони.
+func releaseBookingSeat(ctx context.Context, txRepo *repository.TransactionRepository, bookingID, seatID int64) (*models.Seat, error) {
+	seat, err := txRepo.Seat.GetByIDForUpdate(ctx, seatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seat for update: %w", err)
+	}
+	if seat == nil {
+		return nil, nil
+	}
+
+	links, err := txRepo.BookingSeat.GetBySeatID(ctx, seatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking seats: %w", err)
+	}
+
+	released := false
+	for _, link := range links {
+		if link.BookingID != bookingID {
+			continue
+		}
+		if err := txRepo.BookingSeat.Delete(ctx, link.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete booking seat: %w", err)
+		}
+		released = true
+	}
+	if !released {
+		return nil, nil
+	}
+
+	if err := txRepo.Seat.UpdateStatus(ctx, seatID, models.SeatStatusFree); err != nil {
+		return nil, fmt.Errorf("failed to release seat: %w", err)
+	}
+	return seat, nil
+}
+
+// releaseOrphanSeat освобождает зарезервированное место без брони и возвращает его мероприятие
+func releaseOrphanSeat(ctx context.Context, txRepo *repository.TransactionRepository, seatID int64) (int64, error) {
+	seat, err := txRepo.Seat.GetByIDForUpdate(ctx, seatID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get seat: %w", err)
+	}
+	if seat == nil {
+		return 0, ErrSeatNotFound
+	}
+	if seat.Status != models.SeatStatusReserved {
+		return 0, ErrSeatNotReserved
+	}
+
+	// Пока место блокировалось, его могли выбрать
+	bookingSeats, err := txRepo.BookingSeat.GetBySeatID(ctx, seatID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get booking seats: %w", err)
+	}
+	if len(bookingSeats) > 0 {
+		return 0, ErrSeatNotReserved
+	}
+
+	if err := txRepo.Seat.UpdateStatus(ctx, seatID, models.SeatStatusFree); err != nil {
+		return 0, fmt.Errorf("failed to release seat: %w", err)
+	}
+	return seat.EventID, nil
+}
+
+// invalidateSeats сбрасывает кеш мест мероприятия сразу после фиксации изменений:
+// consumer делает это асинхронно и может отставать или быть выключен (broker.type = none).
+// Ошибка только логируется - изменения уже зафиксированы, кеш истечет по TTL.
+func invalidateSeats(ctx context.Context, cacheClient cache.Cache, eventID int64) {
+	if cacheClient == nil || eventID == 0 {
+		return
+	}
+
+	if err := readmodel.InvalidateSeats(context.WithoutCancel(ctx), cacheClient, eventID); err != nil {
+		logger.FromContext(ctx, zap.L()).Warn("Failed to invalidate seats cache",
+			zap.Int64("event_id", eventID), zap.Error(err))
+	}
+}
+
 // publishEvent отправляет событие в Broker с ID брони в качестве ключа
-func (s *bookingService) publishEvent(eventType models.EventType, bookingID int64, data any) {
-	if s.eventPublisher == nil {
+func (s *bookingService) publishEvent(ctx context.Context, eventType models.EventType, bookingID int64, data any) {
+	publishBookingEvent(ctx, s.eventPublisher, s.bookingTopic, eventType, bookingID, data)
+}
+
+// publishBookingEvent отправляет событие брони в топик с ID брони в качестве ключа
+func publishBookingEvent(ctx context.Context, publisher broker.Publisher, topic string, eventType models.EventType, bookingID int64, data any) {
+	if publisher == nil {
 		return // Graceful degradation если publisher не настроен
 	}
 
+	// Изменения уже зафиксированы в базе, поэтому отмена или дедлайн запроса не должны
+	// терять событие; из ctx сохраняются только trace и request_id
+	ctx = context.WithoutCancel(ctx)
+	log := logger.FromContext(ctx, zap.L()).With(
+		zap.String("event_type", string(eventType)),
+		zap.Int64("booking_id", bookingID))
+
 	bookingIDStr := strconv.FormatInt(bookingID, 10)
-	event := models.NewDomainEvent(eventType, bookingIDStr, data)
+	event, err := eventschema.Default.NewEvent(eventType, bookingIDStr, data)
+	if err != nil {
+		log.Error("Failed to build event", zap.Error(err))
+		return
+	}
 
-	ctx := context.Background()
-	if err := s.eventPublisher.Publish(ctx, s.bookingTopic, bookingIDStr, event); err != nil {
-		// В продакшене здесь должно быть логирование ошибки
-		fmt.Printf("Failed to publish event %s into topic %s for booking %d: %v\n", eventType, s.bookingTopic, bookingID, err)
+	if err := publisher.Publish(ctx, topic, bookingIDStr, event); err != nil {
+		log.Error("Failed to publish event", zap.String("topic", topic), zap.Error(err))
 	}
 }
@@ -5,9 +5,12 @@ import (
 	"biletter-service/internal/domain_events"
 	"biletter-service/internal/repository"
 	"biletter-service/pkg/broker"
+	"biletter-service/pkg/cache"
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -16,22 +19,25 @@ import (
 type ConsumerService struct {
 	consumer      broker.Consumer
 	eventHandlers *domain_events.Handlers
+	repos         *repository.Repository
+	config        config.Kafka
 	logger        *zap.Logger
 	topics        []string
 	wg            sync.WaitGroup
 	cancelFunc    context.CancelFunc
+	stopping      atomic.Bool
 }
 
 // NewConsumerService создает новый ConsumerService
-func NewConsumerService(cfg config.Kafka, groupID string, repos *repository.Repository, logger *zap.Logger) (*ConsumerService, error) {
-	// Создаем consumer
-	consumer, err := broker.NewKafkaConsumer(cfg, groupID)
+func NewConsumerService(brokers *broker.Factory, cfg config.Kafka, groupID string, repos *repository.Repository, cacheClient cache.Cache, logger *zap.Logger) (*ConsumerService, error) {
+	// Создаем consumer выбранного брокера
+	consumer, err := brokers.NewConsumer(groupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create consumer: %w", err)
 	}
 
 	// Создаем обработчики событий
-	eventHandlers := domain_events.NewHandlers(repos, logger)
+	eventHandlers := domain_events.NewHandlers(repos, cacheClient, groupID, logger)
 
 	// Определяем топики для подписки
 	topics := []string{cfg.Topics.BookingEvents}
@@ -39,6 +45,8 @@ func NewConsumerService(cfg config.Kafka, groupID string, repos *repository.Repo
 	return &ConsumerService{
 		consumer:      consumer,
 		eventHandlers: eventHandlers,
+		repos:         repos,
+		config:        cfg,
 		logger:        logger,
 		topics:        topics,
 	}, nil
@@ -63,12 +71,49 @@ func (s *ConsumerService) Start(ctx context.Context) error {
 		}
 	}()
 
+	// Периодически удаляем устаревшие отметки об обработанных событиях
+	if s.config.ProcessedEventsTTL > 0 && s.config.ProcessedEventsCleanupInterval > 0 {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.cleanupProcessedEvents(ctx)
+		}()
+	}
+
 	return nil
 }
 
-// Stop останавливает обработку событий
+// cleanupProcessedEvents удаляет отметки старше ProcessedEventsTTL.
+// Повторная доставка события старше TTL уже не будет распознана как дубль.
+func (s *ConsumerService) cleanupProcessedEvents(ctx context.Context) {
+	ticker := time.NewTicker(s.config.ProcessedEventsCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repos.ProcessedEvent.DeleteProcessedBefore(ctx, time.Now().Add(-s.config.ProcessedEventsTTL))
+			if err != nil {
+				s.logger.Error("Failed to cleanup processed events", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				s.logger.Info("Processed events cleaned up", zap.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// Stop останавливает обработку событий: новые сообщения больше не берутся, начатые
+// дообрабатываются не дольше kafka.drain_timeout, после чего фиксируются offset'ы
 func (s *ConsumerService) Stop() error {
-	s.logger.Info("Stopping consumer service")
+	s.logger.Info("Stopping consumer service",
+		zap.Int64("in_flight", s.Health().InFlight),
+		zap.Duration("drain_timeout", s.config.DrainTimeout))
+
+	s.stopping.Store(true)
 
 	// Отменяем контекст
 	if s.cancelFunc != nil {
@@ -78,7 +123,7 @@ func (s *ConsumerService) Stop() error {
 	// Ждем завершения всех горутин
 	s.wg.Wait()
 
-	// Закрываем consumer
+	// Закрываем consumer: ждет дообработки начатых сообщений
 	if err := s.consumer.Close(); err != nil {
 		s.logger.Error("Failed to close consumer", zap.Error(err))
 		return err
@@ -88,6 +133,25 @@ func (s *ConsumerService) Stop() error {
 	return nil
 }
 
+// Health возвращает состояние consumer'а; для consumer'ов без HealthReporter - пустое
+func (s *ConsumerService) Health() broker.ConsumerHealth {
+	if reporter, ok := s.consumer.(broker.HealthReporter); ok {
+		return reporter.Health()
+	}
+	return broker.ConsumerHealth{}
+}
+
+// Live сообщает, что подписка работает или останавливается штатно
+func (s *ConsumerService) Live() bool {
+	return s.stopping.Load() || s.Health().Running
+}
+
+// Ready сообщает, что consumer обрабатывает сообщения и не останавливается
+func (s *ConsumerService) Ready() bool {
+	health := s.Health()
+	return !s.stopping.Load() && health.Running && health.Ready
+}
+
 // Wait ожидает завершения работы consumer'а
 func (s *ConsumerService) Wait() {
 	s.wg.Wait()
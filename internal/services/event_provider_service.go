@@ -3,11 +3,12 @@ package services
 import (
 	"biletter-service/internal/config"
 	"biletter-service/internal/models"
-	"bytes"
+	"biletter-service/pkg/httpclient"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
@@ -26,17 +27,14 @@ type EventProviderService interface {
 }
 
 type eventProviderService struct {
-	httpClient *http.Client
-	config     config.ExternalService
-	logger     *zap.Logger
+	client *httpclient.Client
+	logger *zap.Logger
 }
 
 func NewEventProviderService(cfg config.ExternalService, logger *zap.Logger) EventProviderService {
+	baseURL := fmt.Sprintf("%s/api/partners/%s", cfg.Hackload.BaseURL, cfg.Hackload.APIVersion)
 	return &eventProviderService{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		config: cfg,
+		client: httpclient.New("event_provider", baseURL, cfg.Hackload.Client),
 		logger: logger,
 	}
 }
@@ -49,27 +47,10 @@ func (s *eventProviderService) CreateOrder(ctx context.Context) (*models.CreateO
 		s.logger.Info("Order created", zap.Duration("exec_time", time.Since(startTime)))
 	}()
 
-	url := fmt.Sprintf("%s/api/partners/%s/orders", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to create order", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var response models.CreateOrderResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodPost, "/orders", nil, &response); err != nil {
+		s.logger.Error("Failed to create order", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Order created successfully", zap.String("order_id", response.OrderID))
@@ -79,27 +60,10 @@ func (s *eventProviderService) CreateOrder(ctx context.Context) (*models.CreateO
 func (s *eventProviderService) GetOrder(ctx context.Context, orderID string) (*models.OrderDetails, error) {
 	s.logger.Info("Getting order details", zap.String("order_id", orderID))
 
-	url := fmt.Sprintf("%s/api/partners/%s/orders/%s", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, orderID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to get order details", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var response models.OrderDetails
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderID), nil, &response); err != nil {
+		s.logger.Error("Failed to get order details", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Order details retrieved", zap.String("status", response.Status))
@@ -109,22 +73,9 @@ func (s *eventProviderService) GetOrder(ctx context.Context, orderID string) (*m
 func (s *eventProviderService) SubmitOrder(ctx context.Context, orderID string) error {
 	s.logger.Info("Submitting order", zap.String("order_id", orderID))
 
-	url := fmt.Sprintf("%s/api/partners/%s/orders/%s/submit", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, orderID)
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
+	if err := s.client.Do(ctx, http.MethodPatch, "/orders/"+url.PathEscape(orderID)+"/submit", nil, nil); err != nil {
 		s.logger.Error("Failed to submit order", zap.Error(err))
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return err
 	}
 
 	s.logger.Info("Order submitted successfully", zap.String("order_id", orderID))
@@ -134,22 +85,9 @@ func (s *eventProviderService) SubmitOrder(ctx context.Context, orderID string)
 func (s *eventProviderService) ConfirmOrder(ctx context.Context, orderID string) error {
 	s.logger.Info("Confirming order", zap.String("order_id", orderID))
 
-	url := fmt.Sprintf("%s/api/partners/%s/orders/%s/confirm", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, orderID)
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
+	if err := s.client.Do(ctx, http.MethodPatch, "/orders/"+url.PathEscape(orderID)+"/confirm", nil, nil); err != nil {
 		s.logger.Error("Failed to confirm order", zap.Error(err))
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return err
 	}
 
 	s.logger.Info("Order confirmed successfully", zap.String("order_id", orderID))
@@ -159,22 +97,9 @@ func (s *eventProviderService) ConfirmOrder(ctx context.Context, orderID string)
 func (s *eventProviderService) CancelOrder(ctx context.Context, orderID string) error {
 	s.logger.Info("Cancelling order", zap.String("order_id", orderID))
 
-	url := fmt.Sprintf("%s/api/partners/%s/orders/%s/cancel", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, orderID)
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
+	if err := s.client.Do(ctx, http.MethodPatch, "/orders/"+url.PathEscape(orderID)+"/cancel", nil, nil); err != nil {
 		s.logger.Error("Failed to cancel order", zap.Error(err))
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return err
 	}
 
 	s.logger.Info("Order cancelled successfully", zap.String("order_id", orderID))
@@ -193,28 +118,15 @@ func (s *eventProviderService) GetPlaces(ctx context.Context, page, pageSize *in
 
 	s.logger.Info("Getting places", zap.Int("page", pageVal), zap.Int("page_size", pageSizeVal))
 
-	url := fmt.Sprintf("%s/api/partners/%s/places?page=%d&pageSize=%d",
-		s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, pageVal, pageSizeVal)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to get places", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	query := url.Values{
+		"page":     {strconv.Itoa(pageVal)},
+		"pageSize": {strconv.Itoa(pageSizeVal)},
 	}
 
 	var places []*models.Place
-	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodGet, "/places", nil, &places, httpclient.Query(query)); err != nil {
+		s.logger.Error("Failed to get places", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Places retrieved", zap.Int("count", len(places)))
@@ -224,33 +136,17 @@ func (s *eventProviderService) GetPlaces(ctx context.Context, page, pageSize *in
 func (s *eventProviderService) GetPlace(ctx context.Context, placeID string) (*models.Place, error) {
 	s.logger.Info("Getting place details", zap.String("place_id", placeID))
 
-	url := fmt.Sprintf("%s/api/partners/%s/places/%s", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, placeID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to get place details", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var place models.Place
-	if err := json.NewDecoder(resp.Body).Decode(&place); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodGet, "/places/"+url.PathEscape(placeID), nil, &place); err != nil {
+		s.logger.Error("Failed to get place details", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Place details retrieved", zap.String("place_id", place.ID))
 	return &place, nil
 }
 
+// SelectPlace повторяется при сбоях: повторный выбор места тем же заказом ничего не меняет
 func (s *eventProviderService) SelectPlace(ctx context.Context, placeID, orderID string) error {
 	s.logger.Info("Selecting place", zap.String("place_id", placeID), zap.String("order_id", orderID))
 
@@ -258,53 +154,22 @@ func (s *eventProviderService) SelectPlace(ctx context.Context, placeID, orderID
 		OrderID: orderID,
 	}
 
-	body, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/api/partners/%s/places/%s/select", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, placeID)
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
+	if err := s.client.Do(ctx, http.MethodPatch, "/places/"+url.PathEscape(placeID)+"/select", request, nil, httpclient.Idempotent()); err != nil {
 		s.logger.Error("Failed to select place", zap.Error(err))
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return err
 	}
 
 	s.logger.Info("Place selected successfully", zap.String("place_id", placeID), zap.String("order_id", orderID))
 	return nil
 }
 
+// ReleasePlace повторяется при сбоях: освобождение свободного места ничего не меняет
 func (s *eventProviderService) ReleasePlace(ctx context.Context, placeID string) error {
 	s.logger.Info("Releasing place", zap.String("place_id", placeID))
 
-	url := fmt.Sprintf("%s/api/partners/%s/places/%s/release", s.config.Hackload.BaseURL, s.config.Hackload.APIVersion, placeID)
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
+	if err := s.client.Do(ctx, http.MethodPatch, "/places/"+url.PathEscape(placeID)+"/release", nil, nil, httpclient.Idempotent()); err != nil {
 		s.logger.Error("Failed to release place", zap.Error(err))
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return err
 	}
 
 	s.logger.Info("Place released successfully", zap.String("place_id", placeID))
@@ -2,18 +2,25 @@ package services
 
 import (
 	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
 	"biletter-service/internal/repository"
 	"biletter-service/pkg/cache"
+	"biletter-service/pkg/logger"
+	"biletter-service/pkg/metrics"
 	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
 type EventService interface {
-	FindEvents(query *string, date *time.Time, page, pageSize int) ([]models.ListEventsResponseItem, error)
-	ClearCache()
+	FindEvents(ctx context.Context, query *string, date *time.Time, page, pageSize int) ([]models.ListEventsResponseItem, error)
+	ClearCache(ctx context.Context)
 }
 
 type eventService struct {
@@ -43,20 +50,26 @@ func (s *eventService) generateCacheKey(query *string, date *time.Time, page, pa
 
 	key := fmt.Sprintf("events:q:%s|d:%s|p:%d|s:%d", queryStr, dateStr, page, pageSize)
 	hash := md5.Sum([]byte(key))
-	return fmt.Sprintf("events:%x", hash)
+	return fmt.Sprintf("%x", hash)
 }
 
 func (s *eventService) getCachedResult(ctx context.Context, cacheKey string) ([]models.ListEventsResponseItem, bool) {
 	val, err := s.cacheClient.Get(ctx, cacheKey)
 	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		metrics.ObserveCache("events", false, err)
 		return nil, false
 	}
 
 	var result []models.ListEventsResponseItem
 	if err := json.Unmarshal([]byte(val), &result); err != nil {
+		metrics.ObserveCache("events", false, err)
 		return nil, false
 	}
 
+	metrics.ObserveCache("events", true, nil)
 	return result, true
 }
 
@@ -69,15 +82,20 @@ func (s *eventService) setCachedResult(ctx context.Context, cacheKey string, dat
 	s.cacheClient.Set(ctx, cacheKey, jsonData, s.cacheTTL)
 }
 
-func (s *eventService) FindEvents(query *string, date *time.Time, page, pageSize int) ([]models.ListEventsResponseItem, error) {
-	ctx := context.Background()
-	cacheKey := s.generateCacheKey(query, date, page, pageSize)
-
-	if cachedResult, found := s.getCachedResult(ctx, cacheKey); found {
-		return cachedResult, nil
+// FindEvents возвращает страницу мероприятий. Кеш версионирован, как и кеш мест:
+// ClearCache и сброс данных увеличивают версию, и старые страницы перестают читаться.
+func (s *eventService) FindEvents(ctx context.Context, query *string, date *time.Time, page, pageSize int) ([]models.ListEventsResponseItem, error) {
+	cacheKey := ""
+	if version, err := readmodel.EventsVersion(ctx, s.cacheClient); err == nil {
+		cacheKey = readmodel.EventsCacheKey(version, s.generateCacheKey(query, date, page, pageSize))
+		if cachedResult, found := s.getCachedResult(ctx, cacheKey); found {
+			return cachedResult, nil
+		}
+	} else {
+		metrics.ObserveCache("events", false, err)
 	}
 
-	events, err := s.eventRepo.FindEvents(query, date, page, pageSize)
+	events, err := s.eventRepo.FindEvents(ctx, query, date, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -90,13 +108,14 @@ func (s *eventService) FindEvents(query *string, date *time.Time, page, pageSize
 		})
 	}
 
-	s.setCachedResult(ctx, cacheKey, response)
+	if cacheKey != "" {
+		s.setCachedResult(ctx, cacheKey, response)
+	}
 	return response, nil
 }
 
-func (s *eventService) ClearCache() {
-	ctx := context.Background()
-	pattern := "events:*"
-
-	s.cacheClient.DelByPattern(ctx, pattern)
+func (s *eventService) ClearCache(ctx context.Context) {
+	if err := readmodel.InvalidateEvents(ctx, s.cacheClient); err != nil {
+		logger.FromContext(ctx, zap.L()).Warn("Failed to invalidate events cache", zap.Error(err))
+	}
 }
@@ -3,15 +3,11 @@ package services
 import (
 	"biletter-service/internal/config"
 	"biletter-service/internal/models"
-	"bytes"
+	"biletter-service/pkg/httpclient"
+	"biletter-service/pkg/paymenttoken"
 	"context"
-	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"reflect"
-	"sort"
-	"time"
 
 	"go.uber.org/zap"
 )
@@ -25,7 +21,7 @@ type PaymentGatewayService interface {
 }
 
 type paymentGatewayService struct {
-	httpClient    *http.Client
+	client        *httpclient.Client
 	paymentConfig config.Payment
 	serviceURL    string
 	logger        *zap.Logger
@@ -33,9 +29,7 @@ type paymentGatewayService struct {
 
 func NewPaymentGatewayService(paymentConfig config.Payment, serviceURL string, logger *zap.Logger) PaymentGatewayService {
 	return &paymentGatewayService{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:        httpclient.New("payment_gateway", paymentConfig.GatewayURL, paymentConfig.Client),
 		paymentConfig: paymentConfig,
 		serviceURL:    serviceURL,
 		logger:        logger,
@@ -49,32 +43,10 @@ func (s *paymentGatewayService) CreatePayment(ctx context.Context, request *mode
 	token := s.generateToken(request)
 	request.Token = token
 
-	body, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/PaymentInit/init", s.paymentConfig.GatewayURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to create payment", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var response models.PaymentInitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodPost, "/PaymentInit/init", request, &response); err != nil {
+		s.logger.Error("Failed to create payment", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Payment created successfully", zap.String("payment_id", response.PaymentID))
@@ -114,32 +86,11 @@ func (s *paymentGatewayService) CheckPaymentStatus(ctx context.Context, paymentI
 	token := s.generateToken(request)
 	request.Token = token
 
-	body, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/PaymentCheck/check", s.paymentConfig.GatewayURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to check payment status", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
+	// Проверка статуса только читает состояние платежа, поэтому повторяется при сбоях
 	var response models.PaymentCheckResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodPost, "/PaymentCheck/check", request, &response, httpclient.Idempotent()); err != nil {
+		s.logger.Error("Failed to check payment status", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Payment status checked successfully")
@@ -159,32 +110,10 @@ func (s *paymentGatewayService) ConfirmPayment(ctx context.Context, paymentID st
 	token := s.generateToken(request)
 	request.Token = token
 
-	body, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/PaymentConfirm/confirm", s.paymentConfig.GatewayURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to confirm payment", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var response models.PaymentConfirmResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodPost, "/PaymentConfirm/confirm", request, &response); err != nil {
+		s.logger.Error("Failed to confirm payment", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Payment confirmed successfully", zap.String("payment_id", paymentID))
@@ -204,32 +133,10 @@ func (s *paymentGatewayService) CancelPayment(ctx context.Context, paymentID, re
 	token := s.generateToken(request)
 	request.Token = token
 
-	body, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/PaymentCancel/cancel", s.paymentConfig.GatewayURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to cancel payment", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var response models.PaymentCancelResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := s.client.Do(ctx, http.MethodPost, "/PaymentCancel/cancel", request, &response); err != nil {
+		s.logger.Error("Failed to cancel payment", zap.Error(err))
+		return nil, err
 	}
 
 	s.logger.Info("Payment cancelled successfully", zap.String("payment_id", paymentID))
@@ -237,88 +144,5 @@ func (s *paymentGatewayService) CancelPayment(ctx context.Context, paymentID, re
 }
 
 func (s *paymentGatewayService) generateToken(request interface{}) string {
-	// Извлекаем поля для генерации токена в алфавитном порядке
-	params := make(map[string]string)
-
-	v := reflect.ValueOf(request).Elem()
-	t := reflect.TypeOf(request).Elem()
-
-	switch req := request.(type) {
-	case *models.PaymentInitRequest:
-		params["amount"] = fmt.Sprintf("%d", req.Amount)
-		params["currency"] = req.Currency
-		params["orderId"] = req.OrderID
-		params["teamSlug"] = req.TeamSlug
-	case *models.PaymentCheckRequest:
-		if req.PaymentID != "" {
-			params["paymentId"] = req.PaymentID
-		}
-		if req.OrderID != "" {
-			params["orderId"] = req.OrderID
-		}
-		params["teamSlug"] = req.TeamSlug
-	case *models.PaymentConfirmRequest:
-		params["amount"] = fmt.Sprintf("%d", req.Amount)
-		params["paymentId"] = req.PaymentID
-		params["teamSlug"] = req.TeamSlug
-	case *models.PaymentCancelRequest:
-		params["paymentId"] = req.PaymentID
-		params["teamSlug"] = req.TeamSlug
-	default:
-		// Generic reflection-based approach
-		for i := 0; i < v.NumField(); i++ {
-			field := t.Field(i)
-			value := v.Field(i)
-
-			// Пропускаем поле Token
-			if field.Name == "Token" {
-				continue
-			}
-
-			jsonTag := field.Tag.Get("json")
-			if jsonTag == "" || jsonTag == "-" {
-				continue
-			}
-
-			// Убираем omitempty из json тега
-			fieldName := jsonTag
-			if idx := len(jsonTag); idx > 0 {
-				if commaIdx := len(jsonTag); commaIdx > 0 {
-					for j, char := range jsonTag {
-						if char == ',' {
-							commaIdx = j
-							break
-						}
-					}
-					if commaIdx < len(jsonTag) {
-						fieldName = jsonTag[:commaIdx]
-					}
-				}
-			}
-
-			if value.IsValid() && !value.IsZero() {
-				params[fieldName] = fmt.Sprintf("%v", value.Interface())
-			}
-		}
-	}
-
-	// Сортируем ключи в алфавитном порядке
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Конкатенируем значения в алфавитном порядке ключей
-	var tokenString string
-	for _, key := range keys {
-		tokenString += params[key]
-	}
-
-	// Добавляем пароль
-	tokenString += s.paymentConfig.Password
-
-	// Генерируем SHA-256 хеш
-	hash := sha256.Sum256([]byte(tokenString))
-	return fmt.Sprintf("%x", hash)
+	return paymenttoken.Generate(request, s.paymentConfig.Password)
 }
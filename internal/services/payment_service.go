@@ -4,42 +4,56 @@ import (
 	"biletter-service/internal/config"
 	"biletter-service/internal/models"
 	"biletter-service/internal/repository"
+	"biletter-service/pkg/broker"
+	"biletter-service/pkg/cache"
+	"biletter-service/pkg/metrics"
 	"context"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type PaymentService interface {
-	InitiatePayment(req *models.InitiatePaymentRequest, userID int) (string, error)
-	ProcessPaymentNotification(payload *models.PaymentNotificationPayload) error
-	NotifyPaymentSuccess(orderID string) error
-	NotifyPaymentFailure(orderID string) error
+	InitiatePayment(ctx context.Context, req *models.InitiatePaymentRequest, userID int) (string, error)
+	ProcessPaymentNotification(ctx context.Context, payload *models.PaymentNotificationPayload) error
+	NotifyPaymentSuccess(ctx context.Context, orderID string) error
+	NotifyPaymentFailure(ctx context.Context, orderID string) error
 }
 
 type paymentService struct {
 	bookingRepo           repository.BookingRepository
+	bookingSeatRepo       repository.BookingSeatRepository
 	paymentConfig         config.Payment
 	paymentGatewayService PaymentGatewayService
 	userService           UserService
 	txManager             *repository.TransactionManager
+	cacheClient           cache.Cache
+	eventPublisher        broker.Publisher
+	bookingTopic          string
 }
 
-func NewPaymentService(bookingRepo repository.BookingRepository, paymentConfig config.Payment, paymentGatewayService PaymentGatewayService, userService UserService, txManager *repository.TransactionManager) PaymentService {
+func NewPaymentService(bookingRepo repository.BookingRepository, bookingSeatRepo repository.BookingSeatRepository, paymentConfig config.Payment, paymentGatewayService PaymentGatewayService, userService UserService, txManager *repository.TransactionManager, cacheClient cache.Cache, eventPublisher broker.Publisher, bookingTopic string) PaymentService {
 	return &paymentService{
 		bookingRepo:           bookingRepo,
+		bookingSeatRepo:       bookingSeatRepo,
 		paymentConfig:         paymentConfig,
 		paymentGatewayService: paymentGatewayService,
 		userService:           userService,
 		txManager:             txManager,
+		cacheClient:           cacheClient,
+		eventPublisher:        eventPublisher,
+		bookingTopic:          bookingTopic,
 	}
 }
 
-func (s *paymentService) InitiatePayment(req *models.InitiatePaymentRequest, userID int) (string, error) {
+func (s *paymentService) InitiatePayment(ctx context.Context, req *models.InitiatePaymentRequest, userID int) (string, error) {
 	var paymentURL string
-	err := s.txManager.WithTransaction(func(txRepo *repository.TransactionRepository) error {
+	var updated *models.Booking
+	err := s.txManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
 		// Используем SELECT FOR UPDATE для предотвращения конкурентного доступа
-		booking, err := txRepo.Booking.GetByIDForUpdate(req.BookingID)
+		booking, err := txRepo.Booking.GetByIDForUpdate(ctx, req.BookingID)
 		if err != nil {
 			return fmt.Errorf("failed to get booking for update: %w", err)
 		}
@@ -57,21 +71,25 @@ func (s *paymentService) InitiatePayment(req *models.InitiatePaymentRequest, use
 			return fmt.Errorf("booking is not in pending status")
 		}
 
+		if !booking.TotalAmount.IsPositive() {
+			return fmt.Errorf("booking has no seats to pay for")
+		}
+
 		// Получаем пользователя для email
-		user, err := s.userService.GetByID(userID)
+		user, err := s.userService.GetByID(ctx, userID)
 		if err != nil {
 			return fmt.Errorf("failed to get user: %w", err)
 		}
 
 		// Обновляем статус на PAYMENT_PENDING
 		booking.Status = models.BookingStatusPaymentPending
-		err = txRepo.Booking.Update(booking)
+		err = txRepo.Booking.Update(ctx, booking)
 		if err != nil {
 			return fmt.Errorf("failed to update booking: %w", err)
 		}
 
 		// Сумма в тыйынах (умножаем на 100)
-		amountInTiyn := booking.TotalAmount.IntPart() * 100
+		amountInTiyn := booking.TotalAmount.Mul(decimal.NewFromInt(100)).IntPart()
 
 		// Создаем запрос на платеж
 		paymentRequest := s.paymentGatewayService.CreatePaymentRequest(
@@ -83,15 +101,16 @@ func (s *paymentService) InitiatePayment(req *models.InitiatePaymentRequest, use
 		)
 
 		// Создаем платеж в платежном шлюзе
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		gatewayCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
 
-		paymentResponse, err := s.paymentGatewayService.CreatePayment(ctx, paymentRequest)
+		paymentResponse, err := s.paymentGatewayService.CreatePayment(gatewayCtx, paymentRequest)
 		if err != nil {
 			return fmt.Errorf("failed to create payment: %w", err)
 		}
 
 		paymentURL = paymentResponse.PaymentURL
+		updated = booking
 		return nil
 	})
 
@@ -99,10 +118,11 @@ func (s *paymentService) InitiatePayment(req *models.InitiatePaymentRequest, use
 		return "", err
 	}
 
+	s.publishPaymentUpdated(ctx, updated, "")
 	return paymentURL, nil
 }
 
-func (s *paymentService) ProcessPaymentNotification(payload *models.PaymentNotificationPayload) error {
+func (s *paymentService) ProcessPaymentNotification(ctx context.Context, payload *models.PaymentNotificationPayload) error {
 	// Поиск бронирования по paymentId или orderId
 	var booking *models.Booking
 	var err error
@@ -114,7 +134,7 @@ func (s *paymentService) ProcessPaymentNotification(payload *models.PaymentNotif
 	if payload.Data != nil {
 		if orderIDRaw, exists := payload.Data["orderId"]; exists {
 			orderID := fmt.Sprintf("%v", orderIDRaw)
-			booking, err = s.bookingRepo.GetByOrderID(orderID)
+			booking, err = s.bookingRepo.GetByOrderID(ctx, orderID)
 			if err != nil {
 				return fmt.Errorf("failed to get booking by order ID: %w", err)
 			}
@@ -125,34 +145,37 @@ func (s *paymentService) ProcessPaymentNotification(payload *models.PaymentNotif
 		return fmt.Errorf("no booking found for payment ID: %s", payload.PaymentID)
 	}
 
-	// Обновляем paymentId если его еще нет
-	if booking.PaymentID == nil {
-		booking.PaymentID = &payload.PaymentID
-	}
+	return s.applyPaymentResult(ctx, booking.ID, payload.Status, func(booking *models.Booking) {
+		// Обновляем paymentId если его еще нет
+		if booking.PaymentID == nil {
+			booking.PaymentID = &payload.PaymentID
+		}
 
-	// Обрабатываем статус платежа
-	switch strings.ToUpper(payload.Status) {
-	case "CONFIRMED", "COMPLETED":
-		booking.Status = models.BookingStatusConfirmed
-	case "FAILED", "CANCELLED", "REJECTED", "EXPIRED":
-		booking.Status = models.BookingStatusCancelled
-	case "AUTHORIZED":
-		// Платеж авторизован, но еще не подтвержден
-		// Оставляем текущий статус
-	default:
-		// Неизвестный статус, оставляем как есть
-	}
+		// Обрабатываем статус платежа
+		switch strings.ToUpper(payload.Status) {
+		case "CONFIRMED", "COMPLETED":
+			booking.Status = models.BookingStatusConfirmed
+		case "FAILED", "CANCELLED", "REJECTED", "EXPIRED":
+			booking.Status = models.BookingStatusCancelled
+		case "AUTHORIZED":
+			// Платеж авторизован, но еще не подтвержден
+			// Оставляем текущий статус
+		default:
+			// Неизвестный статус, оставляем как есть
+		}
+	})
+}
 
-	err = s.bookingRepo.Update(booking)
-	if err != nil {
-		return fmt.Errorf("failed to update booking: %w", err)
-	}
+func (s *paymentService) NotifyPaymentSuccess(ctx context.Context, orderID string) error {
+	return s.notifyPaymentResult(ctx, orderID, models.BookingStatusConfirmed)
+}
 
-	return nil
+func (s *paymentService) NotifyPaymentFailure(ctx context.Context, orderID string) error {
+	return s.notifyPaymentResult(ctx, orderID, models.BookingStatusCancelled)
 }
 
-func (s *paymentService) NotifyPaymentSuccess(orderID string) error {
-	booking, err := s.bookingRepo.GetByOrderID(orderID)
+func (s *paymentService) notifyPaymentResult(ctx context.Context, orderID string, status models.BookingStatus) error {
+	booking, err := s.bookingRepo.GetByOrderID(ctx, orderID)
 	if err != nil {
 		return fmt.Errorf("failed to get booking by order ID: %w", err)
 	}
@@ -161,30 +184,96 @@ func (s *paymentService) NotifyPaymentSuccess(orderID string) error {
 		return fmt.Errorf("booking not found for order ID: %s", orderID)
 	}
 
-	booking.Status = models.BookingStatusConfirmed
-	err = s.bookingRepo.Update(booking)
+	return s.applyPaymentResult(ctx, booking.ID, "", func(booking *models.Booking) {
+		booking.Status = status
+	})
+}
+
+// applyPaymentResult применяет итог оплаты к брони под блокировкой. Если бронь
+// отменяется, ее места освобождаются так же, как при отмене пользователем, иначе
+// они остались бы RESERVED без возможности освободить их.
+func (s *paymentService) applyPaymentResult(ctx context.Context, bookingID int64, paymentStatus string, apply func(booking *models.Booking)) error {
+	var previousStatus models.BookingStatus
+	var updated *models.Booking
+	var releasedSeats []*models.BookingSeat
+
+	err := s.txManager.WithTransaction(ctx, func(txRepo *repository.TransactionRepository) error {
+		booking, err := txRepo.Booking.GetByIDForUpdate(ctx, bookingID)
+		if err != nil {
+			return fmt.Errorf("failed to get booking for update: %w", err)
+		}
+		if booking == nil {
+			return fmt.Errorf("booking not found")
+		}
+
+		previousStatus = booking.Status
+		apply(booking)
+
+		if booking.Status == models.BookingStatusCancelled && previousStatus != models.BookingStatusCancelled {
+			releasedSeats, err = releaseAllBookingSeats(ctx, txRepo, booking)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := txRepo.Booking.Update(ctx, booking); err != nil {
+			return fmt.Errorf("failed to update booking: %w", err)
+		}
+
+		updated = booking
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update booking: %w", err)
+		return err
 	}
 
+	if len(releasedSeats) > 0 {
+		invalidateSeats(ctx, s.cacheClient, updated.EventID)
+		metrics.Seats.WithLabelValues(metrics.SeatReleased).Add(float64(len(releasedSeats)))
+		for _, bookingSeat := range releasedSeats {
+			publishBookingEvent(ctx, s.eventPublisher, s.bookingTopic, models.SeatReleasedEvent, updated.ID, models.SeatReleasedData{
+				BookingID: updated.ID,
+				SeatID:    bookingSeat.SeatID,
+				UserID:    updated.UserID,
+			})
+		}
+	}
+
+	s.observePaymentResult(ctx, previousStatus, updated)
+	s.publishPaymentUpdated(ctx, updated, paymentStatus)
 	return nil
 }
 
-func (s *paymentService) NotifyPaymentFailure(orderID string) error {
-	booking, err := s.bookingRepo.GetByOrderID(orderID)
-	if err != nil {
-		return fmt.Errorf("failed to get booking by order ID: %w", err)
+// observePaymentResult учитывает подтверждение (и проданные места брони) или неуспешную оплату;
+// повторные уведомления с тем же статусом не учитываются
+func (s *paymentService) observePaymentResult(ctx context.Context, previous models.BookingStatus, booking *models.Booking) {
+	if previous == booking.Status {
+		return
 	}
 
-	if booking == nil {
-		return fmt.Errorf("booking not found for order ID: %s", orderID)
+	switch booking.Status {
+	case models.BookingStatusConfirmed:
+		metrics.Bookings.WithLabelValues(metrics.BookingConfirmed).Inc()
+		// Метрика не должна влиять на обработку платежа, ошибку чтения мест пропускаем
+		if bookingSeats, err := s.bookingSeatRepo.GetByBookingID(ctx, booking.ID); err == nil {
+			metrics.Seats.WithLabelValues(metrics.SeatSold).Add(float64(len(bookingSeats)))
+		}
+	case models.BookingStatusCancelled:
+		metrics.Bookings.WithLabelValues(metrics.BookingPaymentFailed).Inc()
 	}
+}
 
-	booking.Status = models.BookingStatusCancelled
-	err = s.bookingRepo.Update(booking)
-	if err != nil {
-		return fmt.Errorf("failed to update booking: %w", err)
+// publishPaymentUpdated публикует новый статус оплаты брони
+func (s *paymentService) publishPaymentUpdated(ctx context.Context, booking *models.Booking, paymentStatus string) {
+	data := models.PaymentUpdatedData{
+		BookingID:     booking.ID,
+		UserID:        booking.UserID,
+		Status:        booking.Status,
+		PaymentStatus: paymentStatus,
+	}
+	if booking.PaymentID != nil {
+		data.PaymentID = *booking.PaymentID
 	}
 
-	return nil
+	publishBookingEvent(ctx, s.eventPublisher, s.bookingTopic, models.PaymentUpdatedEvent, booking.ID, data)
 }
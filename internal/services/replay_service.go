@@ -0,0 +1,148 @@
+package services
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/internal/repository"
+	"biletter-service/pkg/broker"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultReplayBatchSize событий журнала, читаемых за один запрос
+const defaultReplayBatchSize = 500
+
+// EventLogReplayOptions параметры проигрывания журнала booking_event_log
+type EventLogReplayOptions struct {
+	Checkpoint string    // имя, под которым в replay_checkpoints сохраняется прогресс
+	FromID     int64     // начать с записей с id больше FromID
+	From       time.Time // начать с событий не раньше этого времени
+	Restart    bool      // не продолжать с сохраненного прогресса
+	Limit      int       // максимум событий, 0 - без ограничения
+	BatchSize  int
+	DryRun     bool // не сохранять прогресс; обработчик в dry-run не должен ничего менять
+	SkipErrors bool // пропускать события, которые не удалось обработать, вместо остановки
+	// Progress вызывается после каждого события; err - ошибка обработки пропущенного события
+	Progress func(entry models.BookingEventLogEntry, err error)
+}
+
+// ReplayService проигрывает сохраненную историю событий через обработчик
+type ReplayService struct {
+	repos  *repository.Repository
+	logger *zap.Logger
+}
+
+// NewReplayService создает новый ReplayService
+func NewReplayService(repos *repository.Repository, logger *zap.Logger) *ReplayService {
+	return &ReplayService{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// ReplayEventLog проигрывает журнал booking_event_log в порядке записи через handler.
+// Обрабатываются события, записанные к моменту запуска. Позиция сохраняется в replay_checkpoints
+// после каждой пачки, поэтому повторный запуск с тем же Checkpoint продолжает с места остановки.
+func (s *ReplayService) ReplayEventLog(ctx context.Context, handler broker.EventHandler, opts EventLogReplayOptions) (int, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReplayBatchSize
+	}
+
+	position := opts.FromID
+	if !opts.Restart {
+		saved, ok, err := s.repos.Checkpoint.Get(ctx, opts.Checkpoint)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			position = saved
+			s.logger.Info("Resuming event log replay",
+				zap.String("checkpoint", opts.Checkpoint),
+				zap.Int64("position", position))
+		}
+	}
+
+	replayed := 0
+	for opts.Limit <= 0 || replayed < opts.Limit {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		limit := batchSize
+		if opts.Limit > 0 && opts.Limit-replayed < limit {
+			limit = opts.Limit - replayed
+		}
+
+		entries, err := s.repos.BookingEvents.ListAfter(ctx, position, opts.From, limit)
+		if err != nil {
+			return replayed, err
+		}
+		if len(entries) == 0 {
+			return replayed, nil
+		}
+
+		for _, entry := range entries {
+			err := s.replayEntry(ctx, handler, entry)
+			if err != nil {
+				if ctx.Err() != nil {
+					return replayed, s.saveCheckpoint(ctx, opts, position, ctx.Err())
+				}
+				if !opts.SkipErrors {
+					return replayed, s.saveCheckpoint(ctx, opts, position,
+						fmt.Errorf("failed to replay event %s (log id %d): %w", entry.EventID, entry.ID, err))
+				}
+				s.logger.Warn("Skipping event log entry",
+					zap.Int64("id", entry.ID),
+					zap.String("event_id", entry.EventID),
+					zap.Error(err))
+			}
+
+			position = entry.ID
+			replayed++
+
+			if opts.Progress != nil {
+				opts.Progress(entry, err)
+			}
+		}
+
+		if err := s.saveCheckpoint(ctx, opts, position, nil); err != nil {
+			return replayed, err
+		}
+	}
+
+	return replayed, nil
+}
+
+// saveCheckpoint сохраняет позицию (кроме dry-run) и возвращает cause или ошибку сохранения
+func (s *ReplayService) saveCheckpoint(ctx context.Context, opts EventLogReplayOptions, position int64, cause error) error {
+	if opts.DryRun {
+		return cause
+	}
+
+	if err := s.repos.Checkpoint.Save(ctx, opts.Checkpoint, position); err != nil {
+		if cause != nil {
+			s.logger.Error("Failed to save replay checkpoint", zap.Int64("position", position), zap.Error(err))
+			return cause
+		}
+		return err
+	}
+
+	return cause
+}
+
+func (s *ReplayService) replayEntry(ctx context.Context, handler broker.EventHandler, entry models.BookingEventLogEntry) error {
+	event := &models.DomainEvent{
+		ID:          entry.EventID,
+		Type:        entry.EventType,
+		AggregateID: strconv.FormatInt(entry.BookingID, 10),
+		Version:     entry.EventVersion,
+		Data:        entry.Data,
+		Timestamp:   entry.OccurredAt,
+	}
+
+	return handler.Handle(ctx, event)
+}
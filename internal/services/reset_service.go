@@ -1,20 +1,24 @@
 package services
 
 import (
+	"biletter-service/internal/readmodel"
 	"biletter-service/internal/repository"
+	"biletter-service/pkg/cache"
+	"context"
 	"fmt"
 
 	"go.uber.org/zap"
 )
 
 type ResetService interface {
-	ResetAllData() error
+	ResetAllData(ctx context.Context) error
 }
 
 type resetService struct {
 	bookingRepo repository.BookingRepository
 	seatRepo    repository.SeatRepository
 	txManager   *repository.TransactionManager
+	cacheClient cache.Cache
 	logger      *zap.Logger
 }
 
@@ -22,36 +26,49 @@ func NewResetService(
 	bookingRepo repository.BookingRepository,
 	seatRepo repository.SeatRepository,
 	txManager *repository.TransactionManager,
+	cacheClient cache.Cache,
 	logger *zap.Logger,
 ) ResetService {
 	return &resetService{
 		bookingRepo: bookingRepo,
 		seatRepo:    seatRepo,
 		txManager:   txManager,
+		cacheClient: cacheClient,
 		logger:      logger,
 	}
 }
 
-func (s *resetService) ResetAllData() error {
+func (s *resetService) ResetAllData(ctx context.Context) error {
 	s.logger.Info("Starting data reset")
 
 	// Выполняем все операции в одной транзакции
-	return s.txManager.WithTransaction(func(repos *repository.TransactionRepository) error {
+	err := s.txManager.WithTransaction(ctx, func(repos *repository.TransactionRepository) error {
 		// 1. Удаляем все брони и связанные места
-		if err := repos.Booking.DeleteAll(); err != nil {
+		if err := repos.Booking.DeleteAll(ctx); err != nil {
 			s.logger.Error("Failed to delete bookings", zap.Error(err))
 			return fmt.Errorf("failed to delete bookings: %w", err)
 		}
 		s.logger.Info("All bookings deleted")
 
 		// 2. Сбрасываем статус всех мест на FREE
-		if err := repos.Seat.ResetAllStatus(); err != nil {
+		if err := repos.Seat.ResetAllStatus(ctx); err != nil {
 			s.logger.Error("Failed to reset seats status", zap.Error(err))
 			return fmt.Errorf("failed to reset seats status: %w", err)
 		}
 		s.logger.Info("All seats status reset to FREE")
 
-		s.logger.Info("Data reset completed successfully")
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// 3. Сбрасываем счетчики аналитики и кеш мест, которые ведет consumer
+	if err := readmodel.Reset(ctx, s.cacheClient); err != nil {
+		s.logger.Error("Failed to reset read models", zap.Error(err))
+		return fmt.Errorf("failed to reset read models: %w", err)
+	}
+
+	s.logger.Info("Data reset completed successfully")
+	return nil
 }
@@ -2,35 +2,82 @@ package services
 
 import (
 	"biletter-service/internal/models"
+	"biletter-service/internal/readmodel"
 	"biletter-service/internal/repository"
+	"biletter-service/pkg/cache"
+	"biletter-service/pkg/metrics"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/shopspring/decimal"
 )
 
 type SeatService interface {
-	GetSeatsByEvent(eventID int64, status string, row int64, page int64, pageSize int64) ([]models.ListSeatsResponseItem, error)
-	SelectSeat(req *models.SelectSeatRequest) error
-	ReleaseSeat(req *models.ReleaseSeatRequest) error
+	GetSeatsByEvent(ctx context.Context, eventID int64, status string, row int64, page int64, pageSize int64) ([]models.ListSeatsResponseItem, error)
+	SelectSeat(ctx context.Context, req *models.SelectSeatRequest) error
+	ReleaseSeat(ctx context.Context, req *models.ReleaseSeatRequest) error
 	FillSeats()
 }
 
 type seatService struct {
 	seatRepo      repository.SeatRepository
 	eventProvider EventProviderService
+	cacheClient   cache.Cache
+	cacheTTL      time.Duration
 }
 
-func NewSeatService(seatRepo repository.SeatRepository, eventProvider EventProviderService) SeatService {
+func NewSeatService(seatRepo repository.SeatRepository, eventProvider EventProviderService, cacheClient cache.Cache) SeatService {
 	return &seatService{
 		seatRepo:      seatRepo,
 		eventProvider: eventProvider,
+		cacheClient:   cacheClient,
+		// Изменения мест сбрасывают кеш сразу после фиксации, короткий TTL
+		// ограничивает отставание, если сброс версии в Redis не удался
+		cacheTTL: 10 * time.Second,
 	}
 }
 
-func (s *seatService) GetSeatsByEvent(eventID int64, status string, row int64, page int64, pageSize int64) ([]models.ListSeatsResponseItem, error) {
-	seats, err := s.seatRepo.GetByEventID(eventID, status, row, page, pageSize)
+// GetSeatsByEvent возвращает страницу мест. Кеш версионирован: выбор и освобождение мест,
+// отмена брони (BookingService и SeatService) и обработчики событий увеличивают версию
+// мероприятия, и старые страницы перестают читаться.
+func (s *seatService) GetSeatsByEvent(ctx context.Context, eventID int64, status string, row int64, page int64, pageSize int64) ([]models.ListSeatsResponseItem, error) {
+	cacheKey := ""
+	if version, err := readmodel.SeatsVersion(ctx, s.cacheClient, eventID); err == nil {
+		cacheKey = readmodel.SeatsCacheKey(eventID, version, status, row, page, pageSize)
+		cached, err := s.cacheClient.Get(ctx, cacheKey)
+		if err == nil {
+			var response []models.ListSeatsResponseItem
+			if err = json.Unmarshal([]byte(cached), &response); err == nil {
+				metrics.ObserveCache("seats", true, nil)
+				return response, nil
+			}
+		} else if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		metrics.ObserveCache("seats", false, err)
+	}
+
+	response, err := s.loadSeatsByEvent(ctx, eventID, status, row, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheKey != "" {
+		if data, err := json.Marshal(response); err == nil {
+			s.cacheClient.Set(ctx, cacheKey, data, s.cacheTTL)
+		}
+	}
+
+	return response, nil
+}
+
+func (s *seatService) loadSeatsByEvent(ctx context.Context, eventID int64, status string, row int64, page int64, pageSize int64) ([]models.ListSeatsResponseItem, error) {
+	seats, err := s.seatRepo.GetByEventID(ctx, eventID, status, row, page, pageSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get seats: %w", err)
 	}
@@ -49,47 +96,49 @@ func (s *seatService) GetSeatsByEvent(eventID int64, status string, row int64, p
 	return response, nil
 }
 
-func (s *seatService) SelectSeat(req *models.SelectSeatRequest) error {
-	seat, err := s.seatRepo.GetByID(req.SeatID)
+func (s *seatService) SelectSeat(ctx context.Context, req *models.SelectSeatRequest) error {
+	seat, err := s.seatRepo.GetByID(ctx, req.SeatID)
 	if err != nil {
 		return fmt.Errorf("failed to get seat: %w", err)
 	}
 
 	if seat == nil {
-		return fmt.Errorf("seat not found")
+		return ErrSeatNotFound
 	}
 
 	if seat.Status != models.SeatStatusFree {
-		return fmt.Errorf("seat is not available")
+		return ErrSeatNotAvailable
 	}
 
-	err = s.seatRepo.UpdateStatus(req.SeatID, models.SeatStatusReserved)
+	err = s.seatRepo.UpdateStatus(ctx, req.SeatID, models.SeatStatusReserved)
 	if err != nil {
 		return fmt.Errorf("failed to reserve seat: %w", err)
 	}
 
+	invalidateSeats(ctx, s.cacheClient, seat.EventID)
 	return nil
 }
 
-func (s *seatService) ReleaseSeat(req *models.ReleaseSeatRequest) error {
-	seat, err := s.seatRepo.GetByID(req.SeatID)
+func (s *seatService) ReleaseSeat(ctx context.Context, req *models.ReleaseSeatRequest) error {
+	seat, err := s.seatRepo.GetByID(ctx, req.SeatID)
 	if err != nil {
 		return fmt.Errorf("failed to get seat: %w", err)
 	}
 
 	if seat == nil {
-		return fmt.Errorf("seat not found")
+		return ErrSeatNotFound
 	}
 
 	if seat.Status != models.SeatStatusReserved {
-		return fmt.Errorf("seat is not reserved")
+		return ErrSeatNotReserved
 	}
 
-	err = s.seatRepo.UpdateStatus(req.SeatID, models.SeatStatusFree)
+	err = s.seatRepo.UpdateStatus(ctx, req.SeatID, models.SeatStatusFree)
 	if err != nil {
 		return fmt.Errorf("failed to release seat: %w", err)
 	}
 
+	invalidateSeats(ctx, s.cacheClient, seat.EventID)
 	return nil
 }
 
@@ -117,7 +166,7 @@ func (s *seatService) FillSeats() {
 				Status:     models.SeatStatusFree,
 				Price:      price,
 			}
-			err := s.seatRepo.Save(seat)
+			err := s.seatRepo.Save(ctx, seat)
 			if err != nil {
 				log.Printf("failed to save seat: %v", err)
 				return
@@ -19,6 +19,8 @@ type Services struct {
 	PaymentGateway PaymentGatewayService
 	Reset          ResetService
 	Analytics      AnalyticsService
+	Auth           AuthService
+	BookingHistory BookingHistoryService
 }
 
 func New(repos *repository.Repository, cacheClient cache.Cache, eventPublisher broker.Publisher, cfg *config.Config, logger *zap.Logger) *Services {
@@ -32,17 +34,19 @@ func New(repos *repository.Repository, cacheClient cache.Cache, eventPublisher b
 	userService := NewUserService(repos.User)
 
 	// Создаем PaymentService с зависимостями
-	paymentService := NewPaymentService(repos.Booking, cfg.Payment, paymentGateway, userService, repos.TxManager)
+	paymentService := NewPaymentService(repos.Booking, repos.BookingSeat, cfg.Payment, paymentGateway, userService, repos.TxManager, cacheClient, eventPublisher, cfg.Kafka.Topics.BookingEvents)
 
 	return &Services{
 		Event:          NewEventService(repos.Event, cacheClient),
-		Booking:        NewBookingService(repos.Booking, repos.BookingSeat, repos.Seat, repos.Event, repos.TxManager, eventPublisher, cfg.Kafka.Topics.BookingEvents),
-		Seat:           NewSeatService(repos.Seat, eventProvider),
+		Booking:        NewBookingService(repos.Booking, repos.BookingSeat, repos.Seat, repos.Event, repos.TxManager, cacheClient, eventPublisher, cfg.Kafka.Topics.BookingEvents),
+		Seat:           NewSeatService(repos.Seat, eventProvider, cacheClient),
 		Payment:        paymentService,
 		User:           userService,
 		EventProvider:  eventProvider,
 		PaymentGateway: paymentGateway,
-		Reset:          NewResetService(repos.Booking, repos.Seat, repos.TxManager, logger),
-		Analytics:      NewAnalyticsService(repos.Seat, repos.Booking, logger),
+		Reset:          NewResetService(repos.Booking, repos.Seat, repos.TxManager, cacheClient, logger),
+		Analytics:      NewAnalyticsService(repos.Seat, repos.Booking, cacheClient, logger),
+		Auth:           NewAuthService(userService, cacheClient, cfg.Auth),
+		BookingHistory: NewBookingHistoryService(repos.BookingEvents, repos.Booking, logger),
 	}
 }
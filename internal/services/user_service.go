@@ -3,13 +3,31 @@ package services
 import (
 	"biletter-service/internal/models"
 	"biletter-service/internal/repository"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserAlreadyExists = errors.New("user with this email already exists")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInvalidPassword   = errors.New("invalid credentials")
+	ErrInvalidBirthday   = errors.New("invalid birthday format, use YYYY-MM-DD")
 )
 
 type UserService interface {
-	GetByEmail(email string) (*models.User, error)
-	GetByID(userID int) (*models.User, error)
-	ValidateCredentials(email, password string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, userID int) (*models.User, error)
+	ValidateCredentials(ctx context.Context, email, password string) (*models.User, error)
+	Register(ctx context.Context, req *models.RegisterUserRequest) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID int, req *models.UpdateProfileRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, userID int, req *models.ChangePasswordRequest) error
+	Deactivate(ctx context.Context, userID int) error
+	RecordLogin(ctx context.Context, userID int) error
 }
 
 type userService struct {
@@ -22,24 +40,24 @@ func NewUserService(userRepo repository.UserRepository) UserService {
 	}
 }
 
-func (s *userService) GetByEmail(email string) (*models.User, error) {
-	user, err := s.userRepo.GetByEmail(email)
+func (s *userService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, normalizeEmail(email))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 	return user, nil
 }
 
-func (s *userService) GetByID(userID int) (*models.User, error) {
-	user, err := s.userRepo.GetByID(userID)
+func (s *userService) GetByID(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 	return user, nil
 }
 
-func (s *userService) ValidateCredentials(email, password string) (*models.User, error) {
-	user, err := s.userRepo.GetByEmail(email)
+func (s *userService) ValidateCredentials(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, normalizeEmail(email))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -52,10 +70,168 @@ func (s *userService) ValidateCredentials(email, password string) (*models.User,
 		return nil, fmt.Errorf("user is not active")
 	}
 
-	// В Java используется plaintext password, следую той же логике
-	if user.PasswordPlain == nil || *user.PasswordPlain != password {
-		return nil, fmt.Errorf("invalid credentials")
+	if !checkPassword(user, password) {
+		return nil, ErrInvalidPassword
+	}
+
+	return user, nil
+}
+
+func (s *userService) Register(ctx context.Context, req *models.RegisterUserRequest) (*models.User, error) {
+	email := normalizeEmail(req.Email)
+
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrUserAlreadyExists
+	}
+
+	birthday, err := parseBirthday(req.Birthday)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Create(ctx, &models.User{
+		Email:        email,
+		PasswordHash: passwordHash,
+		FirstName:    strings.TrimSpace(req.FirstName),
+		Surname:      strings.TrimSpace(req.Surname),
+		Birthday:     birthday,
+	})
+	if err != nil {
+		// Проверка выше не защищает от параллельной регистрации с тем же email:
+		// вторую вставку отклонит уникальный индекс
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to register user: %w", err)
 	}
 
 	return user, nil
 }
+
+func (s *userService) UpdateProfile(ctx context.Context, userID int, req *models.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Копируем, чтобы не менять объект из кэша до успешной записи в БД
+	updated := *user
+	if req.FirstName != nil {
+		updated.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.Surname != nil {
+		updated.Surname = strings.TrimSpace(*req.Surname)
+	}
+	if req.Birthday != nil {
+		birthday, err := parseBirthday(req.Birthday)
+		if err != nil {
+			return nil, err
+		}
+		updated.Birthday = birthday
+	}
+
+	result, err := s.userRepo.UpdateProfile(ctx, &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+	if result == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return result, nil
+}
+
+func (s *userService) ChangePassword(ctx context.Context, userID int, req *models.ChangePasswordRequest) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if !checkPassword(user, req.CurrentPassword) {
+		return ErrInvalidPassword
+	}
+
+	passwordHash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.userRepo.UpdatePassword(ctx, userID, passwordHash); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return nil
+}
+
+func (s *userService) Deactivate(ctx context.Context, userID int) error {
+	user, err := s.userRepo.SetActive(ctx, userID, false)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate user: %w", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *userService) RecordLogin(ctx context.Context, userID int) error {
+	if _, err := s.userRepo.UpdateLastLoggedIn(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeEmail приводит email к виду, в котором он хранится: регистрация и вход
+// должны находить пользователя независимо от регистра и пробелов во вводе
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkPassword сверяет пароль: пользователи из исходного набора данных хранят
+// plaintext пароль (как в Java версии), зарегистрированные через API - bcrypt хеш
+func checkPassword(user *models.User, password string) bool {
+	if user.PasswordPlain != nil {
+		return *user.PasswordPlain == password
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+func parseBirthday(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	birthday, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, ErrInvalidBirthday
+	}
+
+	return &birthday, nil
+}
@@ -0,0 +1,10 @@
+-- Обработанные доменные события для идемпотентной обработки в consumer'ах
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id       VARCHAR(64)  NOT NULL,
+    consumer_group VARCHAR(255) NOT NULL,
+    event_type     VARCHAR(64)  NOT NULL,
+    processed_at   TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, consumer_group)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events (processed_at);
@@ -0,0 +1,14 @@
+-- Задания на отправку уведомлений, создаются обработчиками доменных событий
+CREATE TABLE IF NOT EXISTS notification_jobs (
+    id          BIGSERIAL    PRIMARY KEY,
+    kind        VARCHAR(64)  NOT NULL,
+    user_id     INTEGER      NOT NULL,
+    booking_id  BIGINT       NOT NULL,
+    payload     JSONB        NOT NULL DEFAULT '{}',
+    status      VARCHAR(32)  NOT NULL DEFAULT 'PENDING',
+    attempts    INTEGER      NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    sent_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_jobs_pending ON notification_jobs (created_at) WHERE status = 'PENDING';
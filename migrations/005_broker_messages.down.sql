@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS broker_offsets;
+DROP TABLE IF EXISTS broker_messages;
@@ -0,0 +1,24 @@
+-- Очередь сообщений для брокера на Postgres (broker.type = postgres)
+CREATE TABLE IF NOT EXISTS broker_messages (
+    id          BIGSERIAL    PRIMARY KEY,
+    -- Транзакция публикации: сообщения читаются только из завершенных транзакций в порядке (tx_id, id)
+    tx_id       XID8         NOT NULL DEFAULT pg_current_xact_id(),
+    topic       VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255) NOT NULL DEFAULT '',
+    payload     JSONB        NOT NULL,
+    headers     JSONB        NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_broker_messages_topic_position ON broker_messages (topic, tx_id, id);
+CREATE INDEX IF NOT EXISTS idx_broker_messages_created_at ON broker_messages (created_at);
+
+-- Позиции групп consumer'ов по топикам
+CREATE TABLE IF NOT EXISTS broker_offsets (
+    consumer_group VARCHAR(255) NOT NULL,
+    topic          VARCHAR(255) NOT NULL,
+    last_tx_id     XID8         NOT NULL DEFAULT '0',
+    last_id        BIGINT       NOT NULL DEFAULT 0,
+    updated_at     TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (consumer_group, topic)
+);
@@ -0,0 +1,13 @@
+-- События, которые асинхронный Kafka producer не смог доставить; переотправляются relay'ем
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id              BIGSERIAL    PRIMARY KEY,
+    topic           VARCHAR(255) NOT NULL,
+    message_key     VARCHAR(255) NOT NULL DEFAULT '',
+    payload         JSONB        NOT NULL,
+    last_error      TEXT,
+    attempts        INTEGER      NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP    NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_next_attempt_at ON event_outbox (next_attempt_at);
@@ -0,0 +1,13 @@
+-- Журнал доменных событий по броням (append-only проекция consumer'а)
+CREATE TABLE IF NOT EXISTS booking_event_log (
+    id            BIGSERIAL   PRIMARY KEY,
+    booking_id    BIGINT      NOT NULL,
+    event_id      VARCHAR(64) NOT NULL UNIQUE,
+    event_type    VARCHAR(64) NOT NULL,
+    event_version INTEGER     NOT NULL,
+    data          JSONB       NOT NULL,
+    occurred_at   TIMESTAMP   NOT NULL,
+    recorded_at   TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_event_log_booking ON booking_event_log (booking_id, occurred_at, id);
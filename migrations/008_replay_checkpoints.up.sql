@@ -0,0 +1,6 @@
+-- Прогресс повторного проигрывания журнала событий (cmd/replay -source log)
+CREATE TABLE IF NOT EXISTS replay_checkpoints (
+    name       VARCHAR(255) PRIMARY KEY,
+    position   BIGINT       NOT NULL,
+    updated_at TIMESTAMP    NOT NULL DEFAULT NOW()
+);
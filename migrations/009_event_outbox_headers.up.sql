@@ -0,0 +1,2 @@
+-- Заголовки сообщения (trace context, request_id, тип и ID события): relay переотправляет их вместе с сообщением
+ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS headers JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- Роль пользователя: права администратора и служебных аккаунтов выдаются в базе, а не по email,
+-- который может занять любой при самостоятельной регистрации
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
@@ -0,0 +1,2 @@
+-- Поиск пользователя по email без учета регистра (вход, регистрация)
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
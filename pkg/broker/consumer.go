@@ -3,6 +3,7 @@ package broker
 import (
 	"biletter-service/internal/models"
 	"context"
+	"errors"
 )
 
 // Consumer интерфейс для потребления событий из брокера сообщений
@@ -22,3 +23,31 @@ type EventHandlerFunc func(ctx context.Context, event *models.DomainEvent) error
 func (f EventHandlerFunc) Handle(ctx context.Context, event *models.DomainEvent) error {
 	return f(ctx, event)
 }
+
+// PermanentError ошибка обработки, которую бессмысленно повторять (например, невалидные данные события).
+// Такие сообщения сразу отправляются в DLQ.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent помечает ошибку как неповторяемую
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent проверяет, помечена ли ошибка как неповторяемая
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
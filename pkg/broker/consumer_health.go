@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ConsumerHealth состояние consumer'а для проверок живости и готовности
+type ConsumerHealth struct {
+	Running       bool      `json:"running"`       // подписка запущена и не остановлена
+	Ready         bool      `json:"ready"`         // consumer получил топики и обрабатывает сообщения
+	InFlight      int64     `json:"in_flight"`     // сообщений в обработке
+	Processed     uint64    `json:"processed"`     // обработанные или пересланные в DLQ
+	Failed        uint64    `json:"failed"`        // сообщения, которые не удалось ни обработать, ни переслать
+	DeadLettered  uint64    `json:"dead_lettered"` // сообщения, которые не удалось обработать и переслали в DLQ
+	LastError     string    `json:"last_error,omitempty"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+// HealthReporter реализуют consumer'ы, которые сообщают свое состояние
+type HealthReporter interface {
+	Health() ConsumerHealth
+}
+
+// consumerState потокобезопасно ведет ConsumerHealth
+type consumerState struct {
+	mu     sync.Mutex
+	health ConsumerHealth
+}
+
+func (s *consumerState) Health() ConsumerHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.health
+}
+
+func (s *consumerState) setRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.health.Running = running
+	if !running {
+		s.health.Ready = false
+	}
+}
+
+func (s *consumerState) setReady(ready bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Ready = ready
+}
+
+func (s *consumerState) setError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.LastError = err.Error()
+}
+
+// begin отмечает начало обработки сообщения
+func (s *consumerState) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.health.InFlight++
+	s.health.LastMessageAt = time.Now()
+}
+
+// end отмечает завершение обработки; err - сообщение не обработано и не переслано
+func (s *consumerState) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.health.InFlight--
+	if err != nil {
+		s.health.Failed++
+		s.health.LastError = err.Error()
+		return
+	}
+	s.health.Processed++
+}
+
+// deadLettered отмечает, что сообщение не обработано с ошибкой err и переслано в DLQ
+func (s *consumerState) deadLettered(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.health.DeadLettered++
+	s.health.LastError = err.Error()
+}
+
+// drainContext возвращает контекст для обработчиков. Он наследует значения parent, но
+// отменяется не вместе с parent, а через timeout после него: за это время начатая
+// обработка успевает завершиться. Вызов cancel отменяет контекст сразу.
+func drainContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+
+	stop := context.AfterFunc(parent, func() {
+		if timeout <= 0 {
+			cancel()
+			return
+		}
+		time.AfterFunc(timeout, cancel)
+	})
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
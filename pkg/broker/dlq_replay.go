@@ -0,0 +1,162 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// HeaderReplayedFromDLQ помечает сообщения, возвращенные из DLQ
+const HeaderReplayedFromDLQ = "replayed_from_dlq"
+
+// DLQReplayOptions параметры переотправки сообщений из DLQ
+type DLQReplayOptions struct {
+	GroupID     string // группа, в которой сохраняется прогресс переотправки
+	TargetTopic string // если пусто - исходный топик сообщения из заголовка
+	Limit       int    // максимум сообщений, 0 - без ограничения
+	DryRun      bool   // только вывести сообщения, не публикуя и не сдвигая offset
+}
+
+// ReplayDeadLetters переотправляет сообщения из DLQ в исходные топики.
+// Обрабатываются сообщения, накопленные к моменту запуска; прогресс сохраняется
+// в offset'ах группы opts.GroupID, поэтому повторный запуск продолжает с места остановки.
+func ReplayDeadLetters(ctx context.Context, cfg config.Kafka, opts DLQReplayOptions) (int, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer client.Close()
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create producer: %w", err)
+	}
+	defer producer.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	offsetManager, err := sarama.NewOffsetManagerFromClient(opts.GroupID, client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create offset manager: %w", err)
+	}
+	defer offsetManager.Close()
+
+	topic := cfg.Topics.DeadLetter
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get partitions of %s: %w", topic, err)
+	}
+
+	replayed := 0
+	for _, partition := range partitions {
+		if opts.Limit > 0 && replayed >= opts.Limit {
+			break
+		}
+
+		count, err := replayPartition(ctx, client, consumer, offsetManager, producer, topic, partition, opts, opts.Limit-replayed)
+		replayed += count
+		if err != nil {
+			return replayed, err
+		}
+	}
+
+	return replayed, nil
+}
+
+func replayPartition(
+	ctx context.Context,
+	client sarama.Client,
+	consumer sarama.Consumer,
+	offsetManager sarama.OffsetManager,
+	producer sarama.SyncProducer,
+	topic string,
+	partition int32,
+	opts DLQReplayOptions,
+	limit int,
+) (int, error) {
+	partitionOffsets, err := offsetManager.ManagePartition(topic, partition)
+	if err != nil {
+		return 0, fmt.Errorf("failed to manage offsets of %s/%d: %w", topic, partition, err)
+	}
+	defer partitionOffsets.Close()
+
+	// Обрабатываем только сообщения, попавшие в DLQ до запуска
+	highWatermark, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get high watermark of %s/%d: %w", topic, partition, err)
+	}
+
+	nextOffset, _ := partitionOffsets.NextOffset()
+	if nextOffset < 0 {
+		nextOffset, err = client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+		}
+	}
+	if nextOffset >= highWatermark {
+		return 0, nil
+	}
+
+	partitionConsumer, err := consumer.ConsumePartition(topic, partition, nextOffset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+	}
+	defer partitionConsumer.Close()
+
+	replayed := 0
+	for {
+		if limit > 0 && replayed >= limit {
+			return replayed, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return replayed, ctx.Err()
+		case err := <-partitionConsumer.Errors():
+			return replayed, fmt.Errorf("failed to read %s/%d: %w", topic, partition, err)
+		case message := <-partitionConsumer.Messages():
+			target := opts.TargetTopic
+			if target == "" {
+				target = originalTopic(message)
+			}
+			errorText, _ := headerValue(message.Headers, HeaderError)
+
+			if opts.DryRun {
+				log.Printf("[dry-run] %s/%d/%d -> %s key=%s error=%q", topic, partition, message.Offset, target, message.Key, errorText)
+			} else {
+				headers := stripRetryHeaders(message.Headers)
+				headers = append(headers, sarama.RecordHeader{Key: []byte(HeaderReplayedFromDLQ), Value: []byte("true")})
+
+				_, _, err := producer.SendMessage(&sarama.ProducerMessage{
+					Topic:   target,
+					Key:     sarama.ByteEncoder(message.Key),
+					Value:   sarama.ByteEncoder(message.Value),
+					Headers: headers,
+				})
+				if err != nil {
+					return replayed, fmt.Errorf("failed to replay %s/%d/%d: %w", topic, partition, message.Offset, err)
+				}
+
+				partitionOffsets.MarkOffset(message.Offset+1, "")
+				log.Printf("Replayed %s/%d/%d -> %s", topic, partition, message.Offset, target)
+			}
+			replayed++
+
+			if message.Offset+1 >= highWatermark {
+				return replayed, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/pkg/database"
+	"database/sql"
+	"fmt"
+)
+
+// Типы брокера, выбираемые настройкой broker.type
+const (
+	TypeKafka    = "kafka"
+	TypePostgres = "postgres"
+	TypeMemory   = "memory"
+	TypeNone     = "none"
+)
+
+// Режимы публикации в Kafka, настройка kafka.producer.mode
+const (
+	ProducerModeSync  = "sync"
+	ProducerModeAsync = "async"
+)
+
+// Factory создает publisher и consumer'ы брокера, выбранного в конфигурации
+type Factory struct {
+	brokerType string
+	broker     config.Broker
+	kafka      config.Kafka
+	database   config.Database
+	db         *sql.DB
+	memory     *MemoryBroker
+}
+
+// NewFactory проверяет тип брокера; db нужен только для broker.type = postgres
+func NewFactory(cfg *config.Config, db *sql.DB) (*Factory, error) {
+	brokerType := cfg.Broker.Type
+	if brokerType == "" {
+		brokerType = TypeKafka
+	}
+
+	factory := &Factory{
+		brokerType: brokerType,
+		broker:     cfg.Broker,
+		kafka:      cfg.Kafka,
+		database:   cfg.Database,
+		db:         db,
+	}
+
+	switch mode := cfg.Kafka.Producer.Mode; mode {
+	case "", ProducerModeSync, ProducerModeAsync:
+	default:
+		return nil, fmt.Errorf("unknown producer mode %q", mode)
+	}
+
+	switch brokerType {
+	case TypeKafka, TypeNone:
+	case TypePostgres:
+		if db == nil {
+			return nil, fmt.Errorf("broker type %s requires a database connection", brokerType)
+		}
+	case TypeMemory:
+		factory.memory = NewMemoryBroker(cfg.Kafka)
+	default:
+		return nil, fmt.Errorf("unknown broker type %q", brokerType)
+	}
+
+	return factory, nil
+}
+
+// Type возвращает выбранный тип брокера
+func (f *Factory) Type() string {
+	return f.brokerType
+}
+
+// InProcess сообщает, что сообщения не покидают процесс и consumer нужно запускать рядом с publisher'ом
+func (f *Factory) InProcess() bool {
+	return f.brokerType == TypeMemory
+}
+
+// NewPublisher создает publisher с метриками публикации; для broker.type = none возвращает nil - события не публикуются
+func (f *Factory) NewPublisher() (Publisher, error) {
+	publisher, err := f.newPublisher()
+	if err != nil {
+		return nil, err
+	}
+	return instrumentPublisher(publisher, f.brokerType), nil
+}
+
+func (f *Factory) newPublisher() (Publisher, error) {
+	switch f.brokerType {
+	case TypeKafka:
+		if f.kafka.Producer.Mode == ProducerModeAsync {
+			var outbox *Outbox
+			if f.db != nil {
+				outbox = NewOutbox(f.db)
+			}
+			return NewAsyncKafkaPublisher(f.kafka, outbox)
+		}
+		return NewKafkaPublisher(f.kafka)
+	case TypePostgres:
+		return NewPostgresPublisher(f.db), nil
+	case TypeMemory:
+		return f.memory, nil
+	default:
+		return nil, nil
+	}
+}
+
+// NewConsumer создает consumer группы groupID
+func (f *Factory) NewConsumer(groupID string) (Consumer, error) {
+	switch f.brokerType {
+	case TypeKafka:
+		return NewKafkaConsumer(f.kafka, groupID)
+	case TypePostgres:
+		return NewPostgresConsumer(f.db, database.DSN(f.database), f.broker.Postgres, f.kafka, groupID)
+	case TypeMemory:
+		return f.memory.Consumer(groupID), nil
+	default:
+		return nil, fmt.Errorf("broker type %s does not support consumers", f.brokerType)
+	}
+}
+
+// Close освобождает ресурсы, общие для publisher'а и consumer'ов
+func (f *Factory) Close() error {
+	if f.memory != nil {
+		return f.memory.Close()
+	}
+	return nil
+}
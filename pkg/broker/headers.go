@@ -0,0 +1,62 @@
+package broker
+
+import (
+	"biletter-service/pkg/requestid"
+	"context"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// messageHeaders возвращает заголовки, связывающие сообщение с породившим его запросом:
+// W3C Trace Context (traceparent, tracestate) и request_id. Имена без префикса x-,
+// поэтому заголовки сохраняются при пересылке в retry/DLQ и при replay.
+func messageHeaders(ctx context.Context) map[string]string {
+	headers := map[string]string{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	if id := requestid.FromContext(ctx); id != "" {
+		headers[requestid.MessageHeader] = id
+	}
+	return headers
+}
+
+// contextFromHeaders восстанавливает контекст трассировки и request_id издателя из заголовков сообщения
+func contextFromHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	if id := headers[requestid.MessageHeader]; id != "" {
+		ctx = requestid.NewContext(ctx, id)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
+
+// contextFromKafkaHeaders восстанавливает контекст издателя из заголовков сообщения Kafka
+func contextFromKafkaHeaders(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	values := make(map[string]string, len(headers))
+	for _, header := range headers {
+		if header != nil {
+			values[string(header.Key)] = string(header.Value)
+		}
+	}
+	return contextFromHeaders(ctx, values)
+}
+
+// producerHeaders переводит заголовки сообщения producer'а в map для хранения в outbox
+func producerHeaders(headers []sarama.RecordHeader) map[string]string {
+	values := make(map[string]string, len(headers))
+	for _, header := range headers {
+		values[string(header.Key)] = string(header.Value)
+	}
+	return values
+}
+
+// recordHeaders восстанавливает заголовки сообщения Kafka из map
+func recordHeaders(values map[string]string) []sarama.RecordHeader {
+	headers := make([]sarama.RecordHeader, 0, len(values))
+	for name, value := range values {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(name), Value: []byte(value)})
+	}
+	return headers
+}
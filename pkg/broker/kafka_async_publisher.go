@@ -0,0 +1,287 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrPublisherBufferFull буфер асинхронного producer'а заполнен
+var ErrPublisherBufferFull = errors.New("publisher buffer is full")
+
+// ErrPublisherClosed публикация после закрытия publisher'а
+var ErrPublisherClosed = errors.New("publisher is closed")
+
+// outboxRelayBatch сообщений, забираемых из outbox за один проход relay'я
+const outboxRelayBatch = 100
+
+// outboxSaveTimeout ограничивает сохранение недоставленного сообщения в outbox
+const outboxSaveTimeout = 5 * time.Second
+
+// asyncMessageMetadata связывает сообщение producer'а с записью outbox (0 - новое сообщение)
+type asyncMessageMetadata struct {
+	outboxID int64
+}
+
+// AsyncKafkaPublisher публикует события без ожидания подтверждения Kafka:
+// сообщения собираются в пачки и отправляются в фоне. Если сообщение не удалось
+// доставить или буфер переполнен, оно сохраняется в outbox и переотправляется позже.
+// Переотправка из outbox может нарушить порядок событий одной брони относительно
+// успешно доставленных, consumer должен быть к этому готов.
+type AsyncKafkaPublisher struct {
+	client        sarama.Client
+	producer      sarama.AsyncProducer
+	pinger        *kafkaPinger
+	outbox        *Outbox
+	relayInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+
+	stats    PublisherStats
+	buffered int64
+
+	cancelRelay context.CancelFunc
+	relayWG     sync.WaitGroup
+	resultsWG   sync.WaitGroup
+}
+
+// NewAsyncKafkaPublisher создает асинхронный publisher; outbox может быть nil -
+// тогда недоставленные сообщения только логируются
+func NewAsyncKafkaPublisher(cfg config.Kafka, outbox *Outbox) (Publisher, error) {
+	producerConfig := cfg.Producer
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.Return.Errors = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = producerConfig.RetryMax
+	saramaConfig.Producer.Partitioner = sarama.NewHashPartitioner
+	saramaConfig.Producer.Flush.Frequency = producerConfig.FlushFrequency
+	saramaConfig.Producer.Flush.Messages = producerConfig.FlushMessages
+	saramaConfig.Producer.Flush.Bytes = producerConfig.FlushBytes
+	saramaConfig.Net.MaxOpenRequests = 1
+	if producerConfig.BufferSize > 0 {
+		saramaConfig.ChannelBufferSize = producerConfig.BufferSize
+	}
+	if producerConfig.Compression != "" {
+		if err := saramaConfig.Producer.Compression.UnmarshalText([]byte(producerConfig.Compression)); err != nil {
+			return nil, fmt.Errorf("invalid producer compression: %w", err)
+		}
+	}
+
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	producer, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka async producer: %w", err)
+	}
+
+	p := &AsyncKafkaPublisher{
+		client:        client,
+		producer:      producer,
+		pinger:        newKafkaPinger(client),
+		outbox:        outbox,
+		relayInterval: producerConfig.OutboxRelayInterval,
+	}
+
+	p.resultsWG.Add(2)
+	go p.handleSuccesses()
+	go p.handleErrors()
+
+	relayCtx, cancel := context.WithCancel(context.Background())
+	p.cancelRelay = cancel
+	if outbox != nil && p.relayInterval > 0 {
+		p.relayWG.Add(1)
+		go p.relay(relayCtx)
+	}
+
+	return p, nil
+}
+
+// Publish ставит событие в буфер отправки и сразу возвращает управление.
+// При переполненном буфере событие сохраняется в outbox.
+func (p *AsyncKafkaPublisher) Publish(ctx context.Context, topic string, key string, event *models.DomainEvent) error {
+	msg, err := newEventMessage(ctx, topic, key, event)
+	if err != nil {
+		return err
+	}
+	msg.Metadata = asyncMessageMetadata{}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPublisherClosed
+	}
+
+	atomic.AddUint64(&p.stats.Published, 1)
+	select {
+	case p.producer.Input() <- msg:
+		atomic.AddInt64(&p.buffered, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return p.saveUndelivered(msg, ErrPublisherBufferFull)
+	}
+}
+
+// Stats возвращает счетчики publisher'а
+func (p *AsyncKafkaPublisher) Stats() PublisherStats {
+	return PublisherStats{
+		Published: atomic.LoadUint64(&p.stats.Published),
+		Delivered: atomic.LoadUint64(&p.stats.Delivered),
+		Failed:    atomic.LoadUint64(&p.stats.Failed),
+		Outboxed:  atomic.LoadUint64(&p.stats.Outboxed),
+		Relayed:   atomic.LoadUint64(&p.stats.Relayed),
+		Dropped:   atomic.LoadUint64(&p.stats.Dropped),
+		Buffered:  atomic.LoadInt64(&p.buffered),
+	}
+}
+
+// Close останавливает relay, отправляет накопленные сообщения и ждет результатов доставки
+func (p *AsyncKafkaPublisher) Close() error {
+	p.cancelRelay()
+	p.relayWG.Wait()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.producer.AsyncClose()
+	p.resultsWG.Wait()
+	return p.client.Close()
+}
+
+// Ping проверяет соединение producer'а с кластером
+func (p *AsyncKafkaPublisher) Ping(ctx context.Context) error {
+	return p.pinger.ping(ctx)
+}
+
+func (p *AsyncKafkaPublisher) handleSuccesses() {
+	defer p.resultsWG.Done()
+
+	for msg := range p.producer.Successes() {
+		atomic.AddInt64(&p.buffered, -1)
+		atomic.AddUint64(&p.stats.Delivered, 1)
+
+		metadata, _ := msg.Metadata.(asyncMessageMetadata)
+		if metadata.outboxID == 0 {
+			continue
+		}
+
+		atomic.AddUint64(&p.stats.Relayed, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), outboxSaveTimeout)
+		if err := p.outbox.Delete(ctx, metadata.outboxID); err != nil {
+			// Сообщение будет отправлено повторно после истечения срока - consumer идемпотентен
+			log.Printf("Failed to delete relayed outbox message %d: %v", metadata.outboxID, err)
+		}
+		cancel()
+	}
+}
+
+func (p *AsyncKafkaPublisher) handleErrors() {
+	defer p.resultsWG.Done()
+
+	for producerErr := range p.producer.Errors() {
+		atomic.AddInt64(&p.buffered, -1)
+		atomic.AddUint64(&p.stats.Failed, 1)
+
+		msg := producerErr.Msg
+		metadata, _ := msg.Metadata.(asyncMessageMetadata)
+		if metadata.outboxID == 0 {
+			p.saveUndelivered(msg, producerErr.Err)
+			continue
+		}
+
+		// Сообщение уже в outbox: сохраняем причину, relay повторит его после истечения срока
+		ctx, cancel := context.WithTimeout(context.Background(), outboxSaveTimeout)
+		if err := p.outbox.Failed(ctx, metadata.outboxID, producerErr.Err); err != nil {
+			log.Printf("Failed to update outbox message %d: %v", metadata.outboxID, err)
+		}
+		cancel()
+	}
+}
+
+// saveUndelivered сохраняет сообщение в outbox; без outbox сообщение теряется
+func (p *AsyncKafkaPublisher) saveUndelivered(msg *sarama.ProducerMessage, cause error) error {
+	if p.outbox == nil {
+		atomic.AddUint64(&p.stats.Dropped, 1)
+		log.Printf("Dropping undelivered message for topic %s: %v", msg.Topic, cause)
+		return fmt.Errorf("failed to publish message to Kafka: %w", cause)
+	}
+
+	key, _ := msg.Key.Encode()
+	value, _ := msg.Value.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboxSaveTimeout)
+	defer cancel()
+
+	if err := p.outbox.Save(ctx, msg.Topic, string(key), value, producerHeaders(msg.Headers), cause); err != nil {
+		atomic.AddUint64(&p.stats.Dropped, 1)
+		log.Printf("Dropping undelivered message for topic %s: %v (%v)", msg.Topic, cause, err)
+		return fmt.Errorf("failed to publish message to Kafka: %w", cause)
+	}
+
+	atomic.AddUint64(&p.stats.Outboxed, 1)
+	return nil
+}
+
+// relay периодически переотправляет сообщения из outbox
+func (p *AsyncKafkaPublisher) relay(ctx context.Context) {
+	defer p.relayWG.Done()
+
+	ticker := time.NewTicker(p.relayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		messages, err := p.outbox.Claim(ctx, outboxRelayBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to read outbox: %v", err)
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			msg := &sarama.ProducerMessage{
+				Topic:    message.topic,
+				Key:      sarama.StringEncoder(message.key),
+				Value:    sarama.ByteEncoder(message.value),
+				Headers:  recordHeaders(message.headers),
+				Metadata: asyncMessageMetadata{outboxID: message.id},
+			}
+
+			select {
+			case p.producer.Input() <- msg:
+				atomic.AddInt64(&p.buffered, 1)
+			case <-ctx.Done():
+				// Невзятые сообщения переотправятся после истечения срока
+				return
+			}
+		}
+	}
+}
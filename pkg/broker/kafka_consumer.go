@@ -3,21 +3,35 @@ package broker
 import (
 	"biletter-service/internal/config"
 	"biletter-service/internal/models"
+	"biletter-service/pkg/metrics"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 )
 
+// consumeRetryInterval пауза перед повторным подключением после ошибки consumer group
+const consumeRetryInterval = time.Second
+
 // KafkaConsumer реализация Consumer для Kafka
 type KafkaConsumer struct {
-	consumerGroup sarama.ConsumerGroup
-	groupID       string
-	ready         chan bool
-	wg            sync.WaitGroup
+	consumerGroup   sarama.ConsumerGroup
+	producer        sarama.SyncProducer
+	groupID         string
+	retry           config.KafkaRetry
+	deadLetterTopic string
+	workers         int
+	drainTimeout    time.Duration
+	state           consumerState
+	wg              sync.WaitGroup
+	closeOnce       sync.Once
+	closeErr        error
 }
 
 // NewKafkaConsumer создает новый Kafka consumer
@@ -27,106 +41,321 @@ func NewKafkaConsumer(cfg config.Kafka, groupID string) (Consumer, error) {
 	config.Consumer.Offsets.Initial = sarama.OffsetOldest
 	config.Consumer.Group.Session.Timeout = 10000
 	config.Consumer.Group.Heartbeat.Interval = 3000
+	// Ребалансировка ждет, пока обработчики дообработают начатые сообщения
+	if rebalanceTimeout := cfg.DrainTimeout + 5*time.Second; rebalanceTimeout > config.Consumer.Group.Rebalance.Timeout {
+		config.Consumer.Group.Rebalance.Timeout = rebalanceTimeout
+	}
 
 	consumerGroup, err := sarama.NewConsumerGroup(cfg.Brokers, groupID, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create consumer group: %w", err)
 	}
 
+	// Producer для пересылки необработанных сообщений в retry и DLQ топики
+	producerConfig := sarama.NewConfig()
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+	producerConfig.Producer.Retry.Max = 3
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, producerConfig)
+	if err != nil {
+		consumerGroup.Close()
+		return nil, fmt.Errorf("failed to create retry producer: %w", err)
+	}
+
+	retry := cfg.Retry
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
 	return &KafkaConsumer{
-		consumerGroup: consumerGroup,
-		groupID:       groupID,
-		ready:         make(chan bool),
+		consumerGroup:   consumerGroup,
+		producer:        producer,
+		groupID:         groupID,
+		retry:           retry,
+		deadLetterTopic: cfg.Topics.DeadLetter,
+		workers:         workers,
+		drainTimeout:    cfg.DrainTimeout,
 	}, nil
 }
 
-// Subscribe подписывается на топики и начинает обработку событий
+// Subscribe подписывается на топики и их цепочки повторов и начинает обработку событий.
+// Возвращает управление после получения первых партиций или отмены ctx. Ошибки consumer group
+// не останавливают подписку: подключение повторяется, а ошибка видна в Health.
 func (c *KafkaConsumer) Subscribe(ctx context.Context, topics []string, handler EventHandler) error {
+	allTopics := retryTopics(topics, len(c.retry.Delays))
+
+	consumer := &consumerGroupHandler{
+		handler:         handler,
+		producer:        c.producer,
+		retry:           c.retry,
+		deadLetterTopic: c.deadLetterTopic,
+		workers:         c.workers,
+		drainTimeout:    c.drainTimeout,
+		state:           &c.state,
+		ready:           make(chan struct{}),
+	}
+
+	c.state.setRunning(true)
+	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
-		c.wg.Add(1)
-
-		consumer := &consumerGroupHandler{
-			handler: handler,
-			ready:   c.ready,
-		}
+		defer c.state.setRunning(false)
 
 		for {
-			select {
-			case <-ctx.Done():
+			// Consume возвращает управление при ребалансировке; новая сессия начинается в следующей итерации
+			err := c.consumerGroup.Consume(ctx, allTopics, consumer)
+			if ctx.Err() != nil || errors.Is(err, sarama.ErrClosedConsumerGroup) {
 				log.Println("Terminating consumer")
 				return
-			default:
-				if err := c.consumerGroup.Consume(ctx, topics, consumer); err != nil {
-					log.Printf("Error from consumer: %v", err)
-					return
-				}
-
-				if ctx.Err() != nil {
+			}
+			if err != nil {
+				log.Printf("Error from consumer: %v", err)
+				c.state.setError(err)
+				if sleepContext(ctx, consumeRetryInterval) != nil {
 					return
 				}
-				c.ready = make(chan bool)
 			}
 		}
 	}()
 
-	<-c.ready
-	log.Printf("Kafka consumer up and running for group %s", c.groupID)
+	select {
+	case <-consumer.ready:
+		log.Printf("Kafka consumer up and running for group %s", c.groupID)
+	case <-ctx.Done():
+	}
 	return nil
 }
 
-// Close закрывает consumer
+// Health возвращает состояние consumer'а
+func (c *KafkaConsumer) Health() ConsumerHealth {
+	return c.state.Health()
+}
+
+// Close останавливает consumer group, дожидается завершения начатой обработки и
+// фиксации offset'ов, затем закрывает producer повторов. Повторный вызов ничего не делает.
 func (c *KafkaConsumer) Close() error {
-	c.wg.Wait()
-	return c.consumerGroup.Close()
+	c.closeOnce.Do(func() {
+		// Закрытие группы завершает сессию: обработчики партиций дообрабатывают начатые сообщения
+		c.closeErr = c.consumerGroup.Close()
+		c.wg.Wait()
+
+		// Producer нужен обработчикам до конца: они пересылают сообщения в retry и DLQ топики
+		if err := c.producer.Close(); err != nil {
+			log.Printf("Failed to close retry producer: %v", err)
+		}
+	})
+	return c.closeErr
 }
 
 // consumerGroupHandler реализует sarama.ConsumerGroupHandler
 type consumerGroupHandler struct {
-	handler EventHandler
-	ready   chan bool
+	handler         EventHandler
+	producer        sarama.SyncProducer
+	retry           config.KafkaRetry
+	deadLetterTopic string
+	workers         int
+	drainTimeout    time.Duration
+	state           *consumerState
+	ready           chan struct{} // закрывается при первой сессии
+	readyOnce       sync.Once
 }
 
 // Setup запускается в начале новой сессии
 func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
-	close(h.ready)
+	h.state.setReady(true)
+	h.readyOnce.Do(func() { close(h.ready) })
 	return nil
 }
 
-// Cleanup запускается в конце сессии
+// Cleanup запускается в конце сессии, после завершения всех ConsumeClaim
 func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	h.state.setReady(false)
 	return nil
 }
 
-// ConsumeClaim обрабатывает сообщения из партиции
+// ConsumeClaim обрабатывает сообщения из партиции. Сообщения распределяются по обработчикам
+// по хешу ключа: разные брони обрабатываются параллельно, события одной брони - по порядку.
+// По окончании сессии (остановка или ребалансировка) новые сообщения не начинаются, а начатые
+// дообрабатываются с контекстом, который отменяется через drainTimeout; offset'ы фиксируются
+// только после завершения обработчиков.
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	sessionCtx := session.Context()
+	handlerCtx, cancelHandlers := drainContext(sessionCtx, h.drainTimeout)
+	defer cancelHandlers()
+
+	tracker := newOffsetTracker(session)
+
+	queues := make([]chan *trackedMessage, h.workers)
+	var workersWG sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *trackedMessage, workerQueueSize)
+
+		workersWG.Add(1)
+		go func(queue <-chan *trackedMessage) {
+			defer workersWG.Done()
+			for tracked := range queue {
+				h.handleMessage(sessionCtx, handlerCtx, tracker, tracked)
+			}
+		}(queues[i])
+	}
+
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		workersWG.Wait()
+
+		if pending := tracker.pending(); pending > 0 {
+			log.Printf("Partition %s/%d drained, %d messages left unprocessed", claim.Topic(), claim.Partition(), pending)
+		}
+		session.Commit()
+	}()
+
 	for {
 		select {
 		case message := <-claim.Messages():
 			if message == nil {
 				return nil
 			}
+			metrics.ObserveKafkaLag(claim.Topic(), claim.Partition(), claim.HighWaterMarkOffset()-message.Offset-1)
 
-			// Десериализуем событие
-			var event models.DomainEvent
-			if err := json.Unmarshal(message.Value, &event); err != nil {
-				log.Printf("Failed to unmarshal event: %v", err)
-				session.MarkMessage(message, "")
-				continue
+			tracked := tracker.add(message)
+			select {
+			case queues[workerIndex(message, h.workers)] <- tracked:
+			case <-sessionCtx.Done():
+				return nil
 			}
 
-			// Обрабатываем событие
-			ctx := context.Background()
-			if err := h.handler.Handle(ctx, &event); err != nil {
-				log.Printf("Failed to handle event %s: %v", event.Type, err)
-				// В продакшене здесь может быть retry logic или DLQ
-			}
+		case <-sessionCtx.Done():
+			return nil
+		}
+	}
+}
+
+// handleMessage обрабатывает одно сообщение в обработчике партиции. После окончания сессии
+// (sessionCtx) новые сообщения не начинаются; начатое обрабатывается с handlerCtx.
+func (h *consumerGroupHandler) handleMessage(sessionCtx, handlerCtx context.Context, tracker *offsetTracker, tracked *trackedMessage) {
+	message := tracked.message
+	if sessionCtx.Err() != nil {
+		return
+	}
+
+	// Сообщения retry топика обрабатываются не раньше назначенного времени;
+	// ожидание прерывается окончанием сессии - сообщение перечитают
+	if err := h.waitRetryDelay(sessionCtx, message); err != nil {
+		return
+	}
+
+	h.state.begin()
+	err := h.processMessage(handlerCtx, message)
+	h.state.end(err)
+	if err != nil {
+		// Сообщение не обработано и не переслано: не подтверждаем, его перечитают после ребалансировки
+		log.Printf("Failed to process message %s/%d/%d: %v", message.Topic, message.Partition, message.Offset, err)
+		return
+	}
 
-			// Подтверждаем обработку
-			session.MarkMessage(message, "")
+	// Подтверждаем обработку
+	tracker.complete(tracked)
+}
+
+// processMessage обрабатывает сообщение с повторами; при исчерпании попыток пересылает
+// его на следующий этап цепочки повторов или в DLQ. Ошибка возвращается только если
+// сообщение не удалось ни обработать, ни переслать.
+func (h *consumerGroupHandler) processMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	// Десериализуем событие
+	var event models.DomainEvent
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		// Повторы не помогут - сразу в DLQ
+		log.Printf("Failed to unmarshal event: %v", err)
+		return h.forward(ctx, message, h.deadLetterTopic, 0, fmt.Errorf("failed to unmarshal event: %w", err), 0)
+	}
+
+	err := handleWithRetry(contextFromKafkaHeaders(ctx, message.Headers), h.handler, &event, h.retry)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		// Обработка прервана остановкой consumer'а - сообщение перечитают
+		return ctx.Err()
+	}
+	if IsPermanent(err) {
+		return h.forward(ctx, message, h.deadLetterTopic, retryStage(message), err, 0)
+	}
 
-		case <-session.Context().Done():
+	stage := retryStage(message)
+	if stage < len(h.retry.Delays) {
+		nextTopic := RetryTopic(originalTopic(message), stage+1)
+		return h.forward(ctx, message, nextTopic, stage+1, err, h.retry.Delays[stage])
+	}
+
+	return h.forward(ctx, message, h.deadLetterTopic, stage, err, 0)
+}
+
+// forward публикует исходное сообщение в retry или DLQ топик, повторяя отправку до успеха или отмены ctx
+func (h *consumerGroupHandler) forward(ctx context.Context, message *sarama.ConsumerMessage, topic string, stage int, cause error, delay time.Duration) error {
+	if topic == "" {
+		log.Printf("Dead letter topic is not configured, dropping message %s/%d/%d: %v", message.Topic, message.Partition, message.Offset, cause)
+		return nil
+	}
+
+	headers := map[string]string{
+		HeaderOriginalTopic: originalTopic(message),
+		HeaderRetryStage:    strconv.Itoa(stage),
+		HeaderError:         cause.Error(),
+		HeaderFailedAt:      time.Now().UTC().Format(time.RFC3339Nano),
+		HeaderAttempts:      strconv.Itoa(h.retry.Attempts),
+	}
+	// Координаты исходного сообщения сохраняем только при первой пересылке
+	if _, ok := headerValue(message.Headers, HeaderOriginalOffset); !ok {
+		headers[HeaderOriginalPartition] = strconv.Itoa(int(message.Partition))
+		headers[HeaderOriginalOffset] = strconv.FormatInt(message.Offset, 10)
+	}
+	if delay > 0 {
+		headers[HeaderRetryNotBefore] = strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: forwardHeaders(message.Headers, headers),
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, _, err := h.producer.SendMessage(msg)
+		if err == nil {
+			log.Printf("Message %s/%d/%d forwarded to %s", message.Topic, message.Partition, message.Offset, topic)
+			if topic == h.deadLetterTopic {
+				h.state.deadLettered(cause)
+			}
 			return nil
 		}
+
+		log.Printf("Failed to forward message to %s (attempt %d): %v", topic, attempt, err)
+		if sleepErr := sleepContext(ctx, backoff(h.retry.Backoff, h.retry.MaxBackoff, attempt)); sleepErr != nil {
+			return fmt.Errorf("failed to forward message to %s: %w", topic, err)
+		}
+	}
+}
+
+// waitRetryDelay выдерживает задержку этапа цепочки повторов
+func (h *consumerGroupHandler) waitRetryDelay(ctx context.Context, message *sarama.ConsumerMessage) error {
+	value, ok := headerValue(message.Headers, HeaderRetryNotBefore)
+	if !ok {
+		return nil
 	}
+
+	notBefore, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return sleepContext(ctx, time.Until(time.UnixMilli(notBefore)))
 }
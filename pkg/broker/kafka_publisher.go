@@ -5,13 +5,18 @@ import (
 	"biletter-service/internal/models"
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/IBM/sarama"
 )
 
 // KafkaPublisher реализация Publisher для Kafka
 type KafkaPublisher struct {
+	client   sarama.Client
 	producer sarama.SyncProducer
+	pinger   *kafkaPinger
+	stats    PublisherStats
 }
 
 // NewKafkaPublisher создает новый Kafka publisher
@@ -21,45 +26,44 @@ func NewKafkaPublisher(cfg config.Kafka) (Publisher, error) {
 	config.Producer.Return.Errors = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 3
-	config.Producer.Partitioner = sarama.NewManualPartitioner
+	// Партиция выбирается по хешу ключа (ID брони): события одной брони попадают
+	// в одну партицию и читаются по порядку
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	// Один запрос в полете на брокер: повтор отправки не переставит сообщения местами
+	config.Net.MaxOpenRequests = 1
 
-	producer, err := sarama.NewSyncProducer(cfg.Brokers, config)
+	client, err := sarama.NewClient(cfg.Brokers, config)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
 	return &KafkaPublisher{
+		client:   client,
 		producer: producer,
+		pinger:   newKafkaPinger(client),
 	}, nil
 }
 
 // Publish отправляет событие в Kafka
 func (p *KafkaPublisher) Publish(ctx context.Context, topic string, key string, event *models.DomainEvent) error {
-	value, err := event.ToJSON()
+	msg, err := newEventMessage(ctx, topic, key, event)
 	if err != nil {
-		return fmt.Errorf("failed to serialize event: %w", err)
-	}
-
-	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   sarama.StringEncoder(key),
-		Value: sarama.ByteEncoder(value),
-		Headers: []sarama.RecordHeader{
-			{
-				Key:   []byte("event_type"),
-				Value: []byte(event.Type),
-			},
-			{
-				Key:   []byte("event_id"),
-				Value: []byte(event.ID),
-			},
-		},
+		return err
 	}
 
+	atomic.AddUint64(&p.stats.Published, 1)
 	partition, offset, err := p.producer.SendMessage(msg)
 	if err != nil {
+		atomic.AddUint64(&p.stats.Failed, 1)
 		return fmt.Errorf("failed to send message to Kafka: %w", err)
 	}
+	atomic.AddUint64(&p.stats.Delivered, 1)
 
 	// Логируем успешную отправку (можно заменить на proper logging)
 	fmt.Printf("Message sent to partition %d at offset %d\n", partition, offset)
@@ -67,7 +71,116 @@ func (p *KafkaPublisher) Publish(ctx context.Context, topic string, key string,
 	return nil
 }
 
+// Stats возвращает счетчики publisher'а
+func (p *KafkaPublisher) Stats() PublisherStats {
+	return PublisherStats{
+		Published: atomic.LoadUint64(&p.stats.Published),
+		Delivered: atomic.LoadUint64(&p.stats.Delivered),
+		Failed:    atomic.LoadUint64(&p.stats.Failed),
+	}
+}
+
+// newEventMessage сериализует событие в сообщение Kafka с ключом, служебными заголовками
+// и контекстом запроса из ctx
+func newEventMessage(ctx context.Context, topic string, key string, event *models.DomainEvent) (*sarama.ProducerMessage, error) {
+	value, err := event.ToJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize event: %w", err)
+	}
+
+	headers := []sarama.RecordHeader{
+		{
+			Key:   []byte("event_type"),
+			Value: []byte(event.Type),
+		},
+		{
+			Key:   []byte("event_id"),
+			Value: []byte(event.ID),
+		},
+	}
+	for name, value := range messageHeaders(ctx) {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(name), Value: []byte(value)})
+	}
+
+	return &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.StringEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	}, nil
+}
+
 // Close закрывает producer
 func (p *KafkaPublisher) Close() error {
-	return p.producer.Close()
+	if err := p.producer.Close(); err != nil {
+		p.client.Close()
+		return err
+	}
+	return p.client.Close()
+}
+
+// Ping проверяет соединение producer'а с кластером
+func (p *KafkaPublisher) Ping(ctx context.Context) error {
+	return p.pinger.ping(ctx)
+}
+
+// kafkaPinger проверяет, что клиенту доступен хотя бы один брокер кластера
+type kafkaPinger struct {
+	client sarama.Client
+
+	mu      sync.Mutex
+	refresh *metadataRefresh // обновление метаданных в процессе, общее для всех проверок
+}
+
+type metadataRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+func newKafkaPinger(client sarama.Client) *kafkaPinger {
+	return &kafkaPinger{client: client}
+}
+
+func (p *kafkaPinger) ping(ctx context.Context) error {
+	if p.client.Closed() {
+		return ErrPublisherClosed
+	}
+
+	for _, broker := range p.client.Brokers() {
+		if connected, _ := broker.Connected(); connected {
+			return nil
+		}
+	}
+
+	// Открытых соединений нет: обновление метаданных заново подключается к брокерам.
+	// RefreshMetadata не принимает контекст и при недоступном кластере может идти
+	// дольше ctx, поэтому одновременно выполняется не больше одного обновления:
+	// проверки, у которых истек ctx, не оставляют новых зависших горутин.
+	p.mu.Lock()
+	refresh := p.refresh
+	if refresh == nil {
+		refresh = &metadataRefresh{done: make(chan struct{})}
+		p.refresh = refresh
+		go p.refreshMetadata(refresh)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		if refresh.err != nil {
+			return fmt.Errorf("kafka is unavailable: %w", refresh.err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *kafkaPinger) refreshMetadata(refresh *metadataRefresh) {
+	refresh.err = p.client.RefreshMetadata()
+
+	p.mu.Lock()
+	p.refresh = nil
+	p.mu.Unlock()
+	close(refresh.done)
 }
@@ -0,0 +1,281 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrBrokerClosed публикация или подписка после закрытия брокера
+var ErrBrokerClosed = errors.New("broker is closed")
+
+type memoryMessage struct {
+	seq     int64
+	topic   string
+	key     string
+	value   []byte            // событие хранится сериализованным, как в Kafka
+	headers map[string]string // контекст трассировки и request_id издателя
+}
+
+// MemoryBroker брокер в памяти процесса для тестов и запуска на одном узле.
+// Как и Kafka, хранит журнал сообщений и позиции групп consumer'ов; группа
+// обрабатывает сообщения строго в порядке публикации, поэтому порядок по ключу сохраняется.
+// Сообщения удаляются из журнала, когда все известные группы прошли их; группа,
+// подписавшаяся позже, начинает с самого старого оставшегося сообщения.
+// Журнал не переживает перезапуск процесса.
+type MemoryBroker struct {
+	mu       sync.Mutex
+	messages []*memoryMessage // журнал начиная с сообщения base
+	base     int64            // seq первого сообщения в messages
+	offsets  map[string]int64 // позиция группы в журнале (seq следующего сообщения)
+	groups   map[string]*sync.Mutex
+	signal   chan struct{} // закрывается при каждой публикации
+	closed   bool
+
+	retry           config.KafkaRetry
+	deadLetterTopic string
+	drainTimeout    time.Duration
+	wg              sync.WaitGroup
+}
+
+// NewMemoryBroker создает брокер в памяти; политика повторов и DLQ берутся из cfg
+func NewMemoryBroker(cfg config.Kafka) *MemoryBroker {
+	return &MemoryBroker{
+		offsets:         make(map[string]int64),
+		groups:          make(map[string]*sync.Mutex),
+		signal:          make(chan struct{}),
+		retry:           cfg.Retry,
+		deadLetterTopic: cfg.Topics.DeadLetter,
+		drainTimeout:    cfg.DrainTimeout,
+	}
+}
+
+// Publish добавляет событие в журнал и будит подписчиков
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, key string, event *models.DomainEvent) error {
+	value, err := event.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize event: %w", err)
+	}
+	return b.append(&memoryMessage{topic: topic, key: key, value: value, headers: messageHeaders(ctx)})
+}
+
+func (b *MemoryBroker) append(message *memoryMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	message.seq = b.base + int64(len(b.messages))
+	b.messages = append(b.messages, message)
+
+	close(b.signal)
+	b.signal = make(chan struct{})
+	return nil
+}
+
+// Consumer возвращает consumer группы groupID. Несколько consumer'ов одной группы
+// делят позицию и обрабатывают сообщения по очереди, поэтому должны подписываться на одни топики.
+func (b *MemoryBroker) Consumer(groupID string) Consumer {
+	return &memoryConsumer{broker: b, groupID: groupID}
+}
+
+// Close останавливает публикацию и ждет завершения подписок
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.signal)
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	return nil
+}
+
+// next возвращает следующее сообщение группы из указанных топиков, не сдвигая позицию.
+// Если сообщений нет - канал, который закроется при следующей публикации.
+func (b *MemoryBroker) next(groupID string, topics map[string]bool) (*memoryMessage, <-chan struct{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	end := b.base + int64(len(b.messages))
+	for offset := max(b.offsets[groupID], b.base); offset < end; offset++ {
+		if message := b.messages[offset-b.base]; topics[message.topic] {
+			b.offsets[groupID] = offset
+			return message, nil, true
+		}
+	}
+	b.offsets[groupID] = end
+	b.trim()
+	return nil, b.signal, !b.closed
+}
+
+// commit сдвигает позицию группы за обработанное сообщение
+func (b *MemoryBroker) commit(groupID string, message *memoryMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.offsets[groupID] <= message.seq {
+		b.offsets[groupID] = message.seq + 1
+	}
+	b.trim()
+}
+
+// trim удаляет из журнала сообщения, которые прошли все группы; вызывается под b.mu
+func (b *MemoryBroker) trim() {
+	if len(b.offsets) == 0 {
+		return
+	}
+
+	committed := b.base + int64(len(b.messages))
+	for _, offset := range b.offsets {
+		committed = min(committed, offset)
+	}
+	if committed <= b.base {
+		return
+	}
+
+	drop := committed - b.base
+	// Обнуляем ссылки, чтобы сообщения освободились до перевыделения массива
+	clear(b.messages[:drop])
+	b.messages = b.messages[drop:]
+	b.base = committed
+}
+
+func (b *MemoryBroker) groupLock(groupID string) *sync.Mutex {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lock, ok := b.groups[groupID]
+	if !ok {
+		lock = &sync.Mutex{}
+		b.groups[groupID] = lock
+	}
+	return lock
+}
+
+// memoryConsumer реализация Consumer поверх MemoryBroker
+type memoryConsumer struct {
+	broker  *MemoryBroker
+	groupID string
+	state   consumerState
+	wg      sync.WaitGroup
+}
+
+// Subscribe запускает обработку в фоне и сразу возвращает управление
+func (c *memoryConsumer) Subscribe(ctx context.Context, topics []string, handler EventHandler) error {
+	subscribed := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		subscribed[topic] = true
+	}
+
+	c.state.setRunning(true)
+	c.state.setReady(true)
+	c.broker.wg.Add(1)
+	c.wg.Add(1)
+	go func() {
+		defer c.broker.wg.Done()
+		defer c.wg.Done()
+		defer c.state.setRunning(false)
+		c.consume(ctx, subscribed, handler)
+	}()
+
+	log.Printf("In-memory consumer up and running for group %s", c.groupID)
+	return nil
+}
+
+// consume обрабатывает сообщения, пока не отменен ctx. Начатое сообщение
+// дообрабатывается с контекстом, который отменяется через drainTimeout после ctx.
+func (c *memoryConsumer) consume(ctx context.Context, topics map[string]bool, handler EventHandler) {
+	groupLock := c.broker.groupLock(c.groupID)
+
+	handlerCtx, cancelHandler := drainContext(ctx, c.broker.drainTimeout)
+	defer cancelHandler()
+
+	for {
+		groupLock.Lock()
+		message, wait, open := c.broker.next(c.groupID, topics)
+		if message != nil {
+			c.process(handlerCtx, message, handler)
+		}
+		groupLock.Unlock()
+
+		if message != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		if !open {
+			return
+		}
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// process обрабатывает сообщение с повторами; при неудаче отправляет его в DLQ.
+// Если обработку прервала отмена ctx, позиция не сдвигается.
+func (c *memoryConsumer) process(ctx context.Context, message *memoryMessage, handler EventHandler) {
+	c.state.begin()
+
+	var event models.DomainEvent
+	err := json.Unmarshal(message.value, &event)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal event: %w", err)
+	} else if err = handleWithRetry(contextFromHeaders(ctx, message.headers), handler, &event, c.broker.retry); err != nil && ctx.Err() != nil {
+		c.state.end(ctx.Err())
+		return
+	}
+
+	if err != nil && c.deadLetter(message, err) {
+		c.state.deadLettered(err)
+		err = nil
+	}
+	c.state.end(err)
+	c.broker.commit(c.groupID, message)
+}
+
+// deadLetter пересылает сообщение в DLQ; false - сообщение отброшено
+func (c *memoryConsumer) deadLetter(message *memoryMessage, cause error) bool {
+	if c.broker.deadLetterTopic == "" {
+		log.Printf("Dead letter topic is not configured, dropping message %d: %v", message.seq, cause)
+		return false
+	}
+
+	err := c.broker.append(&memoryMessage{
+		topic:   c.broker.deadLetterTopic,
+		key:     message.key,
+		value:   message.value,
+		headers: message.headers,
+	})
+	if err != nil {
+		log.Printf("Failed to move message %d to %s: %v", message.seq, c.broker.deadLetterTopic, err)
+		return false
+	}
+	log.Printf("Message %d moved to %s: %v", message.seq, c.broker.deadLetterTopic, cause)
+	return true
+}
+
+// Health возвращает состояние consumer'а
+func (c *memoryConsumer) Health() ConsumerHealth {
+	return c.state.Health()
+}
+
+// Close ждет завершения подписок после отмены их ctx; журнал принадлежит MemoryBroker
+func (c *memoryConsumer) Close() error {
+	c.wg.Wait()
+	return nil
+}
@@ -0,0 +1,114 @@
+package broker
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/pkg/metrics"
+	"biletter-service/pkg/tracing"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// instrumentedPublisher учитывает длительность и результат публикации и ведет спан публикации
+type instrumentedPublisher struct {
+	Publisher
+	brokerType string
+}
+
+// instrumentPublisher добавляет к publisher'у метрики публикации и экспортирует его счетчики
+func instrumentPublisher(publisher Publisher, brokerType string) Publisher {
+	if publisher == nil {
+		return nil
+	}
+
+	if provider, ok := publisher.(StatsProvider); ok {
+		metrics.Register(newStatsCollector(provider, brokerType))
+		return &instrumentedStatsPublisher{
+			instrumentedPublisher: instrumentedPublisher{Publisher: publisher, brokerType: brokerType},
+			provider:              provider,
+		}
+	}
+
+	return &instrumentedPublisher{Publisher: publisher, brokerType: brokerType}
+}
+
+func (p *instrumentedPublisher) Publish(ctx context.Context, topic string, key string, event *models.DomainEvent) error {
+	ctx, span := startPublishSpan(ctx, p.brokerType, topic, event)
+	start := time.Now()
+	err := p.Publisher.Publish(ctx, topic, key, event)
+	metrics.BrokerPublishDuration.WithLabelValues(p.brokerType, topic, metrics.Result(err)).Observe(time.Since(start).Seconds())
+	tracing.End(span, err)
+	return err
+}
+
+// Ping проверяет соединение обернутого publisher'а, если он это поддерживает
+func (p *instrumentedPublisher) Ping(ctx context.Context) error {
+	if pinger, ok := p.Publisher.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// instrumentedStatsPublisher сохраняет StatsProvider у обернутого publisher'а
+type instrumentedStatsPublisher struct {
+	instrumentedPublisher
+	provider StatsProvider
+}
+
+func (p *instrumentedStatsPublisher) Stats() PublisherStats {
+	return p.provider.Stats()
+}
+
+// statsCollector экспортирует PublisherStats в Prometheus
+type statsCollector struct {
+	provider StatsProvider
+
+	published *prometheus.Desc
+	delivered *prometheus.Desc
+	failed    *prometheus.Desc
+	outboxed  *prometheus.Desc
+	relayed   *prometheus.Desc
+	dropped   *prometheus.Desc
+	buffered  *prometheus.Desc
+}
+
+func newStatsCollector(provider StatsProvider, brokerType string) *statsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName("biletter", "publisher", name), help,
+			nil, prometheus.Labels{"broker": brokerType})
+	}
+
+	return &statsCollector{
+		provider:  provider,
+		published: desc("published_total", "Events accepted for sending."),
+		delivered: desc("delivered_total", "Events acknowledged by the broker."),
+		failed:    desc("failed_total", "Event delivery errors."),
+		outboxed:  desc("outboxed_total", "Events saved to the outbox for redelivery."),
+		relayed:   desc("relayed_total", "Events redelivered from the outbox."),
+		dropped:   desc("dropped_total", "Events neither delivered nor saved to the outbox."),
+		buffered:  desc("buffered", "Events waiting to be sent in memory."),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.published
+	ch <- c.delivered
+	ch <- c.failed
+	ch <- c.outboxed
+	ch <- c.relayed
+	ch <- c.dropped
+	ch <- c.buffered
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.Stats()
+	ch <- prometheus.MustNewConstMetric(c.published, prometheus.CounterValue, float64(stats.Published))
+	ch <- prometheus.MustNewConstMetric(c.delivered, prometheus.CounterValue, float64(stats.Delivered))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(c.outboxed, prometheus.CounterValue, float64(stats.Outboxed))
+	ch <- prometheus.MustNewConstMetric(c.relayed, prometheus.CounterValue, float64(stats.Relayed))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(stats.Buffered))
+}
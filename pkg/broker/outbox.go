@@ -0,0 +1,99 @@
+package broker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// outboxLease время, на которое relay забирает сообщение; если подтверждение
+// доставки не пришло, по истечении срока сообщение переотправляется
+const outboxLease = time.Minute
+
+type outboxMessage struct {
+	id      int64
+	topic   string
+	key     string
+	value   []byte
+	headers map[string]string
+}
+
+// Outbox хранит недоставленные события в таблице event_outbox
+type Outbox struct {
+	db *sql.DB
+}
+
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+// Save сохраняет сообщение вместе с заголовками для переотправки
+func (o *Outbox) Save(ctx context.Context, topic, key string, value []byte, headers map[string]string, cause error) error {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to save message to outbox: %w", err)
+	}
+
+	_, err = o.db.ExecContext(ctx, `
+		INSERT INTO event_outbox (topic, message_key, payload, headers, last_error)
+		VALUES ($1, $2, $3, $4, $5)`, topic, key, value, headersJSON, cause.Error())
+	if err != nil {
+		return fmt.Errorf("failed to save message to outbox: %w", err)
+	}
+	return nil
+}
+
+// Claim забирает до limit сообщений, готовых к переотправке, и продлевает их срок,
+// чтобы relay другой реплики не отправил их одновременно
+func (o *Outbox) Claim(ctx context.Context, limit int) ([]outboxMessage, error) {
+	rows, err := o.db.QueryContext(ctx, `
+		UPDATE event_outbox
+		SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM event_outbox
+			WHERE next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, message_key, payload, headers`, limit, time.Now().Add(outboxLease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []outboxMessage
+	for rows.Next() {
+		var message outboxMessage
+		var headersJSON []byte
+		if err := rows.Scan(&message.id, &message.topic, &message.key, &message.value, &headersJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		if err := json.Unmarshal(headersJSON, &message.headers); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message %d headers: %w", message.id, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// Delete удаляет доставленное сообщение
+func (o *Outbox) Delete(ctx context.Context, id int64) error {
+	if _, err := o.db.ExecContext(ctx, `DELETE FROM event_outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete outbox message: %w", err)
+	}
+	return nil
+}
+
+// Failed сохраняет причину неудачной переотправки; сообщение будет повторено после истечения срока
+func (o *Outbox) Failed(ctx context.Context, id int64, cause error) error {
+	if _, err := o.db.ExecContext(ctx, `UPDATE event_outbox SET last_error = $2 WHERE id = $1`, id, cause.Error()); err != nil {
+		return fmt.Errorf("failed to update outbox message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package broker
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// workerQueueSize буфер очереди одного обработчика партиции
+const workerQueueSize = 64
+
+// workerIndex выбирает обработчик по ключу сообщения: сообщения одного ключа
+// всегда попадают к одному обработчику и обрабатываются по порядку.
+// Сообщения без ключа распределяются по offset'у.
+func workerIndex(message *sarama.ConsumerMessage, workers int) int {
+	if len(message.Key) == 0 {
+		return int(message.Offset % int64(workers))
+	}
+
+	hash := fnv.New32a()
+	hash.Write(message.Key)
+	return int(hash.Sum32() % uint32(workers))
+}
+
+// offsetTracker подтверждает offset'ы партиции при параллельной обработке.
+// Offset подтверждается, только когда обработаны все сообщения до него включительно,
+// поэтому после перезапуска не теряется ни одно необработанное сообщение.
+type offsetTracker struct {
+	mu       sync.Mutex
+	session  sarama.ConsumerGroupSession
+	inflight []*trackedMessage // в порядке offset'ов
+}
+
+type trackedMessage struct {
+	message *sarama.ConsumerMessage
+	done    bool
+}
+
+func newOffsetTracker(session sarama.ConsumerGroupSession) *offsetTracker {
+	return &offsetTracker{session: session}
+}
+
+// add регистрирует сообщение; вызывается в порядке чтения из партиции
+func (t *offsetTracker) add(message *sarama.ConsumerMessage) *trackedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked := &trackedMessage{message: message}
+	t.inflight = append(t.inflight, tracked)
+	return tracked
+}
+
+// complete отмечает сообщение обработанным и подтверждает непрерывный обработанный префикс
+func (t *offsetTracker) complete(tracked *trackedMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked.done = true
+
+	n := 0
+	for n < len(t.inflight) && t.inflight[n].done {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	t.session.MarkMessage(t.inflight[n-1].message, "")
+	t.inflight = t.inflight[n:]
+}
+
+// pending возвращает количество сообщений, offset которых еще не подтвержден
+func (t *offsetTracker) pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.inflight)
+}
@@ -0,0 +1,169 @@
+package broker
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// markingSession сессия, запоминающая подтвержденные offset'ы
+type markingSession struct {
+	sarama.ConsumerGroupSession
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *markingSession) MarkMessage(message *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, message.Offset)
+}
+
+func TestOffsetTrackerCommitsContiguousPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		offsets     []int64
+		completeIdx []int   // порядок завершения обработки (индексы в offsets)
+		wantMarked  []int64 // подтвержденные offset'ы по порядку
+		wantPending int
+	}{
+		{
+			name:        "in order",
+			offsets:     []int64{10, 11, 12},
+			completeIdx: []int{0, 1, 2},
+			wantMarked:  []int64{10, 11, 12},
+		},
+		{
+			name:        "reverse order",
+			offsets:     []int64{10, 11, 12},
+			completeIdx: []int{2, 1, 0},
+			wantMarked:  []int64{12},
+		},
+		{
+			name:        "gap waits for earliest message",
+			offsets:     []int64{10, 11, 12, 13},
+			completeIdx: []int{1, 3, 0},
+			wantMarked:  []int64{11},
+			wantPending: 2,
+		},
+		{
+			name:        "gap closes later",
+			offsets:     []int64{10, 11, 12, 13},
+			completeIdx: []int{1, 3, 0, 2},
+			wantMarked:  []int64{11, 13},
+		},
+		{
+			name:        "nothing completed",
+			offsets:     []int64{10, 11},
+			wantPending: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &markingSession{}
+			tracker := newOffsetTracker(session)
+
+			tracked := make([]*trackedMessage, len(tt.offsets))
+			for i, offset := range tt.offsets {
+				tracked[i] = tracker.add(&sarama.ConsumerMessage{Offset: offset})
+			}
+			for _, i := range tt.completeIdx {
+				tracker.complete(tracked[i])
+			}
+
+			if !reflect.DeepEqual(session.marked, tt.wantMarked) {
+				t.Fatalf("marked offsets = %v, want %v", session.marked, tt.wantMarked)
+			}
+			if got := tracker.pending(); got != tt.wantPending {
+				t.Fatalf("pending() = %d, want %d", got, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerConcurrentComplete(t *testing.T) {
+	session := &markingSession{}
+	tracker := newOffsetTracker(session)
+
+	const messages = 1000
+	tracked := make([]*trackedMessage, messages)
+	for i := range tracked {
+		tracked[i] = tracker.add(&sarama.ConsumerMessage{Offset: int64(i)})
+	}
+
+	var wg sync.WaitGroup
+	for _, message := range tracked {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.complete(message)
+		}()
+	}
+	wg.Wait()
+
+	if got := tracker.pending(); got != 0 {
+		t.Fatalf("pending() = %d, want 0", got)
+	}
+	last := int64(-1)
+	for _, offset := range session.marked {
+		if offset <= last {
+			t.Fatalf("marked offsets %v are not increasing", session.marked)
+		}
+		last = offset
+	}
+	if last != messages-1 {
+		t.Fatalf("last marked offset = %d, want %d", last, messages-1)
+	}
+}
+
+func TestWorkerIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *sarama.ConsumerMessage
+		workers int
+	}{
+		{name: "keyed", message: &sarama.ConsumerMessage{Key: []byte("booking-42"), Offset: 7}, workers: 8},
+		{name: "keyed single worker", message: &sarama.ConsumerMessage{Key: []byte("booking-42"), Offset: 7}, workers: 1},
+		{name: "unkeyed", message: &sarama.ConsumerMessage{Offset: 13}, workers: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := workerIndex(tt.message, tt.workers)
+			if got < 0 || got >= tt.workers {
+				t.Fatalf("workerIndex() = %d, want in [0, %d)", got, tt.workers)
+			}
+			if again := workerIndex(tt.message, tt.workers); again != got {
+				t.Fatalf("workerIndex() = %d then %d, want stable", got, again)
+			}
+		})
+	}
+}
+
+func TestWorkerIndexSameKeySameWorker(t *testing.T) {
+	const workers = 8
+	keys := []string{"booking-1", "booking-2", "booking-3", "event-10", "user-99"}
+
+	for _, key := range keys {
+		want := workerIndex(&sarama.ConsumerMessage{Key: []byte(key), Offset: 0}, workers)
+		// Offset не влияет на выбор обработчика для сообщения с ключом
+		for offset := int64(1); offset < 100; offset++ {
+			got := workerIndex(&sarama.ConsumerMessage{Key: []byte(key), Offset: offset}, workers)
+			if got != want {
+				t.Fatalf("key %q offset %d: worker %d, want %d", key, offset, got, want)
+			}
+		}
+	}
+}
+
+func TestWorkerIndexUnkeyedRoundRobin(t *testing.T) {
+	const workers = 4
+	for offset := int64(0); offset < 12; offset++ {
+		if got, want := workerIndex(&sarama.ConsumerMessage{Offset: offset}, workers), int(offset%workers); got != want {
+			t.Fatalf("offset %d: worker %d, want %d", offset, got, want)
+		}
+	}
+}
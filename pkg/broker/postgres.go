@@ -0,0 +1,353 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresNotifyChannel канал NOTIFY, которым publisher будит consumer'ов
+const postgresNotifyChannel = "broker_messages"
+
+// postgresCleanupInterval период удаления сообщений старше retention
+const postgresCleanupInterval = time.Hour
+
+// PostgresPublisher реализация Publisher поверх таблицы broker_messages
+type PostgresPublisher struct {
+	db *sql.DB
+}
+
+// NewPostgresPublisher создает publisher; соединение с базой принадлежит вызывающему
+func NewPostgresPublisher(db *sql.DB) Publisher {
+	return &PostgresPublisher{db: db}
+}
+
+// Publish сохраняет событие в очередь и уведомляет consumer'ов через NOTIFY
+func (p *PostgresPublisher) Publish(ctx context.Context, topic string, key string, event *models.DomainEvent) error {
+	value, err := event.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize event: %w", err)
+	}
+
+	if err := insertBrokerMessage(ctx, p.db, topic, key, value, messageHeaders(ctx)); err != nil {
+		return fmt.Errorf("failed to publish message to Postgres: %w", err)
+	}
+	return nil
+}
+
+// Close ничего не делает: соединение с базой закрывает владелец
+func (p *PostgresPublisher) Close() error {
+	return nil
+}
+
+// Ping проверяет соединение с базой, в которой хранится очередь
+func (p *PostgresPublisher) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertBrokerMessage(ctx context.Context, executor sqlExecutor, topic, key string, value []byte, headers map[string]string) error {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO broker_messages (topic, message_key, payload, headers)
+			VALUES ($1, $2, $3, $4)
+			RETURNING topic
+		)
+		SELECT pg_notify($5, topic) FROM inserted`
+
+	_, err = executor.ExecContext(ctx, query, topic, key, value, headersJSON, postgresNotifyChannel)
+	return err
+}
+
+// PostgresConsumer реализация Consumer поверх таблицы broker_messages.
+// Позиция группы хранится в broker_offsets и блокируется на время обработки пачки,
+// поэтому в каждой группе топик обрабатывает один экземпляр и порядок сообщений сохраняется.
+// Сообщения читаются в порядке (tx_id, id) только из завершенных транзакций: так позиция
+// не перескакивает через сообщения, транзакция которых еще не зафиксирована.
+type PostgresConsumer struct {
+	db              *sql.DB
+	listener        *pq.Listener
+	groupID         string
+	settings        config.BrokerPostgres
+	retry           config.KafkaRetry
+	deadLetterTopic string
+	drainTimeout    time.Duration
+	state           consumerState
+	wg              sync.WaitGroup
+}
+
+// NewPostgresConsumer создает consumer; dsn нужен для отдельного соединения LISTEN
+func NewPostgresConsumer(db *sql.DB, dsn string, settings config.BrokerPostgres, cfg config.Kafka, groupID string) (Consumer, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Postgres broker listener event %d: %v", event, err)
+		}
+	})
+	if err := listener.Listen(postgresNotifyChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen %s: %w", postgresNotifyChannel, err)
+	}
+
+	if settings.PollInterval <= 0 {
+		settings.PollInterval = time.Second
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 100
+	}
+
+	return &PostgresConsumer{
+		db:              db,
+		listener:        listener,
+		groupID:         groupID,
+		settings:        settings,
+		retry:           cfg.Retry,
+		deadLetterTopic: cfg.Topics.DeadLetter,
+		drainTimeout:    cfg.DrainTimeout,
+	}, nil
+}
+
+// Subscribe запускает обработку в фоне и сразу возвращает управление
+func (c *PostgresConsumer) Subscribe(ctx context.Context, topics []string, handler EventHandler) error {
+	c.state.setRunning(true)
+	c.state.setReady(true)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer c.state.setRunning(false)
+		c.consume(ctx, topics, handler)
+	}()
+
+	log.Printf("Postgres consumer up and running for group %s", c.groupID)
+	return nil
+}
+
+// Health возвращает состояние consumer'а
+func (c *PostgresConsumer) Health() ConsumerHealth {
+	return c.state.Health()
+}
+
+// Close ждет завершения обработки и закрывает LISTEN соединение
+func (c *PostgresConsumer) Close() error {
+	c.wg.Wait()
+	return c.listener.Close()
+}
+
+// consume обрабатывает пачки сообщений, пока не отменен ctx. После отмены новые сообщения
+// не начинаются, а начатая пачка дообрабатывается с контекстом, который отменяется через
+// drainTimeout после ctx, и позиция фиксируется.
+func (c *PostgresConsumer) consume(ctx context.Context, topics []string, handler EventHandler) {
+	handlerCtx, cancelHandler := drainContext(ctx, c.drainTimeout)
+	defer cancelHandler()
+
+	ticker := time.NewTicker(c.settings.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+
+	for {
+		hasMore := false
+		for _, topic := range topics {
+			if ctx.Err() != nil {
+				return
+			}
+			full, err := c.processBatch(ctx, handlerCtx, topic, handler)
+			if err != nil {
+				c.state.setError(err)
+				if ctx.Err() == nil {
+					log.Printf("Failed to process Postgres broker batch for %s: %v", topic, err)
+				}
+			}
+			hasMore = hasMore || full
+		}
+
+		if c.settings.Retention > 0 && time.Since(lastCleanup) >= postgresCleanupInterval {
+			c.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if hasMore {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.listener.Notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch обрабатывает очередную пачку сообщений топика. Возвращает true,
+// если пачка заполнена целиком и, вероятно, есть еще сообщения. Запросы и обработчик
+// используют handlerCtx; после отмены stopCtx оставшиеся сообщения пачки не начинаются.
+func (c *PostgresConsumer) processBatch(stopCtx, ctx context.Context, topic string, handler EventHandler) (bool, error) {
+	if _, err := c.db.ExecContext(ctx, `
+		INSERT INTO broker_offsets (consumer_group, topic)
+		VALUES ($1, $2)
+		ON CONFLICT (consumer_group, topic) DO NOTHING`, c.groupID, topic); err != nil {
+		return false, fmt.Errorf("failed to init offset: %w", err)
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Позицию группы держит один экземпляр; остальные пропускают топик до следующего опроса
+	var lastTxID string
+	var lastID int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT last_tx_id::text, last_id FROM broker_offsets
+		WHERE consumer_group = $1 AND topic = $2
+		FOR UPDATE SKIP LOCKED`, c.groupID, topic).Scan(&lastTxID, &lastID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock offset: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, tx_id::text, message_key, payload, headers FROM broker_messages
+		WHERE topic = $1
+		  AND (tx_id, id) > ($2::xid8, $3)
+		  AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY tx_id, id
+		LIMIT $4`, topic, lastTxID, lastID, c.settings.BatchSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to query messages: %w", err)
+	}
+
+	type postgresMessage struct {
+		id      int64
+		txID    string
+		key     string
+		value   []byte
+		headers map[string]string
+	}
+	var messages []postgresMessage
+	for rows.Next() {
+		var message postgresMessage
+		var headersJSON []byte
+		if err := rows.Scan(&message.id, &message.txID, &message.key, &message.value, &headersJSON); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if len(headersJSON) > 0 {
+			// Заголовки нужны только для трассировки и request_id, поврежденные не мешают обработке
+			_ = json.Unmarshal(headersJSON, &message.headers)
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	processed := 0
+	for _, message := range messages {
+		if stopCtx.Err() != nil {
+			break
+		}
+
+		c.state.begin()
+		err := c.processMessage(ctx, tx, topic, message.id, message.key, message.value, message.headers, handler)
+		c.state.end(err)
+		if err != nil {
+			break
+		}
+		lastTxID, lastID = message.txID, message.id
+		processed++
+	}
+
+	if processed > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE broker_offsets SET last_tx_id = $3::xid8, last_id = $4, updated_at = NOW()
+			WHERE consumer_group = $1 AND topic = $2`, c.groupID, topic, lastTxID, lastID); err != nil {
+			return false, fmt.Errorf("failed to update offset: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit offset: %w", err)
+	}
+
+	return processed == c.settings.BatchSize, stopCtx.Err()
+}
+
+// processMessage обрабатывает сообщение с повторами; при неудаче перекладывает его в DLQ
+// в транзакции позиции. Ошибка возвращается, только если обработку прервала отмена ctx.
+func (c *PostgresConsumer) processMessage(ctx context.Context, tx *sql.Tx, topic string, id int64, key string, value []byte, messageHeaders map[string]string, handler EventHandler) error {
+	var event models.DomainEvent
+	err := json.Unmarshal(value, &event)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal event: %w", err)
+	} else if err = handleWithRetry(contextFromHeaders(ctx, messageHeaders), handler, &event, c.retry); err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if c.deadLetterTopic == "" {
+		log.Printf("Dead letter topic is not configured, dropping message %s/%d: %v", topic, id, err)
+		return nil
+	}
+
+	headers := map[string]string{}
+	for name, value := range messageHeaders {
+		headers[name] = value
+	}
+	headers[HeaderOriginalTopic] = topic
+	headers[HeaderOriginalOffset] = strconv.FormatInt(id, 10)
+	headers[HeaderError] = err.Error()
+	headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+	headers[HeaderAttempts] = strconv.Itoa(c.retry.Attempts)
+	if dlqErr := insertBrokerMessage(ctx, tx, c.deadLetterTopic, key, value, headers); dlqErr != nil {
+		return fmt.Errorf("failed to move message %s/%d to %s: %w", topic, id, c.deadLetterTopic, dlqErr)
+	}
+
+	log.Printf("Message %s/%d moved to %s: %v", topic, id, c.deadLetterTopic, err)
+	c.state.deadLettered(err)
+	return nil
+}
+
+// cleanup удаляет сообщения старше retention независимо от того, прочитаны ли они
+func (c *PostgresConsumer) cleanup(ctx context.Context) {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM broker_messages WHERE created_at < $1`,
+		time.Now().Add(-c.settings.Retention))
+	if err != nil {
+		log.Printf("Failed to cleanup Postgres broker messages: %v", err)
+		return
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		log.Printf("Deleted %d expired Postgres broker messages", deleted)
+	}
+}
@@ -10,3 +10,24 @@ type Publisher interface {
 	Publish(ctx context.Context, topic string, key string, event *models.DomainEvent) error
 	Close() error
 }
+
+// PublisherStats счетчики publisher'а с момента создания
+type PublisherStats struct {
+	Published uint64 // принято к отправке
+	Delivered uint64 // подтверждено брокером
+	Failed    uint64 // ошибки доставки
+	Outboxed  uint64 // сохранено в outbox для переотправки
+	Relayed   uint64 // переотправлено из outbox и доставлено
+	Dropped   uint64 // потеряно: не доставлено и не сохранено в outbox
+	Buffered  int64  // ожидает отправки в памяти
+}
+
+// Pinger реализуют publisher'ы, которые могут проверить соединение с брокером
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// StatsProvider реализуют publisher'ы, которые ведут счетчики
+type StatsProvider interface {
+	Stats() PublisherStats
+}
@@ -0,0 +1,217 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ReplayOptions параметры повторного проигрывания топика через обработчик
+type ReplayOptions struct {
+	GroupID    string    // группа, в offset'ах которой сохраняется прогресс
+	FromOffset int64     // начальный offset во всех партициях, -1 - не задан
+	From       time.Time // начать с первого сообщения не раньше этого времени, если offset не задан
+	Restart    bool      // не продолжать с сохраненного прогресса, начать с FromOffset/From
+	Limit      int       // максимум сообщений, 0 - без ограничения
+	DryRun     bool      // не сохранять прогресс; обработчик в dry-run не должен ничего менять
+	SkipErrors bool      // пропускать сообщения, которые не удалось обработать, вместо остановки
+	// Progress вызывается после каждого сообщения; err - ошибка обработки пропущенного сообщения
+	Progress func(position ReplayPosition, event *models.DomainEvent, err error)
+}
+
+// ReplayPosition позиция проигрывания в партиции
+type ReplayPosition struct {
+	Topic         string
+	Partition     int32
+	Offset        int64
+	HighWatermark int64 // offset, до которого идет проигрывание
+}
+
+// ReplayTopic проигрывает сообщения топика через handler. Обрабатываются сообщения,
+// накопленные к моменту запуска. Прогресс сохраняется в offset'ах группы opts.GroupID
+// только после успешной обработки, поэтому повторный запуск продолжает с места остановки.
+// Если обработка сообщения не удалась, проигрывание останавливается с ошибкой.
+func ReplayTopic(ctx context.Context, cfg config.Kafka, topic string, handler EventHandler, opts ReplayOptions) (int, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	offsetManager, err := sarama.NewOffsetManagerFromClient(opts.GroupID, client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create offset manager: %w", err)
+	}
+	defer offsetManager.Close()
+
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get partitions of %s: %w", topic, err)
+	}
+
+	replayer := &topicReplayer{
+		client:        client,
+		consumer:      consumer,
+		offsetManager: offsetManager,
+		handler:       handler,
+		retry:         cfg.Retry,
+		topic:         topic,
+		opts:          opts,
+	}
+
+	replayed := 0
+	for _, partition := range partitions {
+		if opts.Limit > 0 && replayed >= opts.Limit {
+			break
+		}
+
+		count, err := replayer.replayPartition(ctx, partition, opts.Limit-replayed)
+		replayed += count
+		if err != nil {
+			return replayed, err
+		}
+	}
+
+	return replayed, nil
+}
+
+type topicReplayer struct {
+	client        sarama.Client
+	consumer      sarama.Consumer
+	offsetManager sarama.OffsetManager
+	handler       EventHandler
+	retry         config.KafkaRetry
+	topic         string
+	opts          ReplayOptions
+}
+
+// startOffset выбирает начальный offset партиции: сохраненный прогресс группы,
+// затем явный offset, затем первое сообщение после opts.From, иначе самое старое
+func (r *topicReplayer) startOffset(partition int32, partitionOffsets sarama.PartitionOffsetManager, highWatermark int64) (int64, error) {
+	if !r.opts.Restart {
+		if nextOffset, _ := partitionOffsets.NextOffset(); nextOffset >= 0 {
+			return nextOffset, nil
+		}
+	}
+
+	oldest, err := r.client.GetOffset(r.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest offset of %s/%d: %w", r.topic, partition, err)
+	}
+
+	switch {
+	case r.opts.FromOffset >= 0:
+		if r.opts.FromOffset < oldest {
+			log.Printf("Offset %d of %s/%d is already deleted, starting from %d", r.opts.FromOffset, r.topic, partition, oldest)
+			return oldest, nil
+		}
+		return r.opts.FromOffset, nil
+	case !r.opts.From.IsZero():
+		offset, err := r.client.GetOffset(r.topic, partition, r.opts.From.UnixMilli())
+		if err != nil {
+			return 0, fmt.Errorf("failed to get offset of %s/%d at %s: %w", r.topic, partition, r.opts.From, err)
+		}
+		if offset < 0 {
+			// Сообщений после From нет
+			return highWatermark, nil
+		}
+		return offset, nil
+	default:
+		return oldest, nil
+	}
+}
+
+func (r *topicReplayer) replayPartition(ctx context.Context, partition int32, limit int) (int, error) {
+	partitionOffsets, err := r.offsetManager.ManagePartition(r.topic, partition)
+	if err != nil {
+		return 0, fmt.Errorf("failed to manage offsets of %s/%d: %w", r.topic, partition, err)
+	}
+	defer partitionOffsets.Close()
+
+	// Проигрываем только сообщения, опубликованные до запуска
+	highWatermark, err := r.client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get high watermark of %s/%d: %w", r.topic, partition, err)
+	}
+
+	nextOffset, err := r.startOffset(partition, partitionOffsets, highWatermark)
+	if err != nil {
+		return 0, err
+	}
+	if nextOffset >= highWatermark {
+		return 0, nil
+	}
+
+	log.Printf("Replaying %s/%d from offset %d to %d", r.topic, partition, nextOffset, highWatermark)
+
+	partitionConsumer, err := r.consumer.ConsumePartition(r.topic, partition, nextOffset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume %s/%d: %w", r.topic, partition, err)
+	}
+	defer partitionConsumer.Close()
+
+	replayed := 0
+	for {
+		if limit > 0 && replayed >= limit {
+			return replayed, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return replayed, ctx.Err()
+		case err := <-partitionConsumer.Errors():
+			return replayed, fmt.Errorf("failed to read %s/%d: %w", r.topic, partition, err)
+		case message := <-partitionConsumer.Messages():
+			position := ReplayPosition{Topic: r.topic, Partition: partition, Offset: message.Offset, HighWatermark: highWatermark}
+
+			event, err := r.replayMessage(ctx, message)
+			if err != nil {
+				if ctx.Err() != nil {
+					return replayed, ctx.Err()
+				}
+				if !r.opts.SkipErrors {
+					return replayed, fmt.Errorf("failed to replay %s/%d/%d: %w", r.topic, partition, message.Offset, err)
+				}
+				log.Printf("Skipping %s/%d/%d: %v", r.topic, partition, message.Offset, err)
+			}
+
+			if !r.opts.DryRun {
+				partitionOffsets.MarkOffset(message.Offset+1, "")
+			}
+			replayed++
+
+			if r.opts.Progress != nil {
+				r.opts.Progress(position, event, err)
+			}
+
+			if message.Offset+1 >= highWatermark {
+				return replayed, nil
+			}
+		}
+	}
+}
+
+// replayMessage разбирает сообщение и передает событие обработчику
+func (r *topicReplayer) replayMessage(ctx context.Context, message *sarama.ConsumerMessage) (*models.DomainEvent, error) {
+	var event models.DomainEvent
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	return &event, handleWithRetry(contextFromKafkaHeaders(ctx, message.Headers), r.handler, &event, r.retry)
+}
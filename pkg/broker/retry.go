@@ -0,0 +1,167 @@
+package broker
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/internal/models"
+	"biletter-service/pkg/metrics"
+	"biletter-service/pkg/tracing"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Заголовки, которыми consumer помечает сообщения при пересылке в retry/DLQ топики
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderRetryStage        = "x-retry-stage"
+	HeaderRetryNotBefore    = "x-retry-not-before"
+	HeaderError             = "x-error"
+	HeaderFailedAt          = "x-failed-at"
+	HeaderAttempts          = "x-attempts"
+)
+
+// RetryTopic возвращает имя N-го топика цепочки повторов для базового топика
+func RetryTopic(topic string, stage int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, stage)
+}
+
+// retryTopics возвращает базовые топики вместе с их цепочками повторов
+func retryTopics(topics []string, stages int) []string {
+	result := make([]string, 0, len(topics)*(stages+1))
+	for _, topic := range topics {
+		result = append(result, topic)
+		for stage := 1; stage <= stages; stage++ {
+			result = append(result, RetryTopic(topic, stage))
+		}
+	}
+	return result
+}
+
+// backoff вычисляет экспоненциальную паузу перед попыткой attempt (начиная с 1)
+func backoff(initial, max time.Duration, attempt int) time.Duration {
+	delay := initial
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
+
+// handleWithRetry вызывает обработчик до retry.Attempts раз с экспоненциальной паузой.
+// Неповторяемая ошибка возвращается сразу; если ожидание прервано отменой ctx, возвращается ctx.Err().
+func handleWithRetry(ctx context.Context, handler EventHandler, event *models.DomainEvent, retry config.KafkaRetry) (err error) {
+	ctx, span := startHandleSpan(ctx, event)
+	start := time.Now()
+	defer func() {
+		metrics.BrokerHandleDuration.WithLabelValues(string(event.Type), metrics.Result(err)).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}()
+
+	attempts := retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = handler.Handle(ctx, event); err == nil {
+			return nil
+		}
+
+		log.Printf("Failed to handle event %s (attempt %d/%d): %v", event.Type, attempt, attempts, err)
+		if IsPermanent(err) {
+			return err
+		}
+		if attempt < attempts {
+			if sleepErr := sleepContext(ctx, backoff(retry.Backoff, retry.MaxBackoff, attempt)); sleepErr != nil {
+				return sleepErr
+			}
+		}
+	}
+
+	return err
+}
+
+// sleepContext ждет d или отмены ctx
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func headerValue(headers []*sarama.RecordHeader, key string) (string, bool) {
+	for _, header := range headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+// originalTopic возвращает топик, в который сообщение было опубликовано изначально
+func originalTopic(message *sarama.ConsumerMessage) string {
+	if topic, ok := headerValue(message.Headers, HeaderOriginalTopic); ok && topic != "" {
+		return topic
+	}
+	return message.Topic
+}
+
+// retryStage возвращает номер этапа цепочки повторов (0 для базового топика)
+func retryStage(message *sarama.ConsumerMessage) int {
+	if value, ok := headerValue(message.Headers, HeaderRetryStage); ok {
+		if stage, err := strconv.Atoi(value); err == nil {
+			return stage
+		}
+	}
+	return 0
+}
+
+// forwardHeaders копирует заголовки исходного сообщения, заменяя служебные x-* заголовки
+func forwardHeaders(headers []*sarama.RecordHeader, overrides map[string]string) []sarama.RecordHeader {
+	result := make([]sarama.RecordHeader, 0, len(headers)+len(overrides))
+	for _, header := range headers {
+		if header == nil {
+			continue
+		}
+		if _, replaced := overrides[string(header.Key)]; replaced {
+			continue
+		}
+		result = append(result, sarama.RecordHeader{Key: header.Key, Value: header.Value})
+	}
+
+	for key, value := range overrides {
+		result = append(result, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+
+	return result
+}
+
+// stripRetryHeaders убирает служебные заголовки retry/DLQ перед повторной публикацией
+func stripRetryHeaders(headers []*sarama.RecordHeader) []sarama.RecordHeader {
+	result := make([]sarama.RecordHeader, 0, len(headers))
+	for _, header := range headers {
+		if header == nil || strings.HasPrefix(string(header.Key), "x-") {
+			continue
+		}
+		result = append(result, sarama.RecordHeader{Key: header.Key, Value: header.Value})
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package broker
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/pkg/requestid"
+	"biletter-service/pkg/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startPublishSpan начинает спан публикации события
+func startPublishSpan(ctx context.Context, brokerType, topic string, event *models.DomainEvent) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(brokerType),
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingMessageID(event.ID),
+			attribute.String("event.type", string(event.Type)),
+		))
+}
+
+// startHandleSpan начинает спан обработки события; родитель - спан публикации из заголовков
+func startHandleSpan(ctx context.Context, event *models.DomainEvent) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{
+		semconv.MessagingMessageID(event.ID),
+		attribute.String("event.type", string(event.Type)),
+		attribute.String("event.aggregate_id", event.AggregateID),
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		attributes = append(attributes, attribute.String("request.id", id))
+	}
+
+	return tracing.Start(ctx, "handle "+string(event.Type),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attributes...))
+}
@@ -4,18 +4,28 @@ import (
 	"biletter-service/internal/config"
 	"context"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 // Cache определяет интерфейс для работы с кешем
 type Cache interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 	Get(ctx context.Context, key string) (string, error)
 	Del(ctx context.Context, keys ...string) error
 	Exists(ctx context.Context, keys ...string) (int64, error)
 	DelByPattern(ctx context.Context, pattern string) error
+	Publish(ctx context.Context, channel string, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+	Incr(ctx context.Context, key string) (int64, error)
+	HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	Ping(ctx context.Context) error
 	Close() error
 }
 
@@ -32,6 +42,11 @@ func NewRedisCache(cfg config.Redis) Cache {
 		DB:       cfg.DB,
 	})
 
+	// Команды Redis попадают в трассировку как дочерние спаны запроса
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		log.Printf("Failed to instrument Redis tracing: %v", err)
+	}
+
 	return &RedisCache{client: client}
 }
 
@@ -39,6 +54,11 @@ func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, exp
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+// SetNX устанавливает значение только если ключ еще не существует
+func (r *RedisCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
 }
@@ -61,6 +81,66 @@ func (r *RedisCache) DelByPattern(ctx context.Context, pattern string) error {
 	return iter.Err()
 }
 
+func (r *RedisCache) Publish(ctx context.Context, channel string, message string) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe подписывается на канал; канал сообщений закрывается при отмене ctx
+func (r *RedisCache) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubsub := r.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		redisMessages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-redisMessages:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// Eval выполняет Lua скрипт атомарно на стороне Redis
+func (r *RedisCache) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return redis.NewScript(script).Run(ctx, r.client, keys, args...).Result()
+}
+
+func (r *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+func (r *RedisCache) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	return r.client.HIncrBy(ctx, key, field, incr).Result()
+}
+
+// HGetAll возвращает все поля хеша; для отсутствующего ключа - пустую map
+func (r *RedisCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, key).Result()
+}
+
+func (r *RedisCache) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *RedisCache) Close() error {
 	return r.client.Close()
 }
@@ -2,17 +2,35 @@ package database
 
 import (
 	"biletter-service/internal/config"
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func New(cfg config.Database) (*sql.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+// DSN строка подключения lib/pq
+func DSN(cfg config.Database) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+}
 
-	db, err := sql.Open("postgres", dsn)
+// New открывает пул соединений; запросы с контекстом попадают в трассировку как дочерние спаны
+func New(cfg config.Database) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", DSN(cfg),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			// Без родительского спана (фоновые задачи, опрос очередей) запросы не трассируются
+			SpanFilter: func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
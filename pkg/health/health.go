@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы проверок и отчета
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // не прошла необязательная проверка, сервис обслуживает запросы
+	StatusFail     = "fail"
+)
+
+// Check проверяет одну зависимость; details - необязательное пояснение к результату
+type Check func(ctx context.Context) (details string, err error)
+
+// CheckResult результат проверки зависимости
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latency_ms"`
+	Details   string  `json:"details,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report результат всех проверок
+type Report struct {
+	Status    string                 `json:"status"`
+	Service   string                 `json:"service"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// Ready сообщает, прошли ли все обязательные проверки
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	required bool
+}
+
+// Checker выполняет проверки зависимостей параллельно, каждую со своим таймаутом
+type Checker struct {
+	service string
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+func NewChecker(service string, timeout time.Duration) *Checker {
+	return &Checker{
+		service: service,
+		timeout: timeout,
+	}
+}
+
+// Add добавляет обязательную проверку: при ее неудаче сервис не готов
+func (c *Checker) Add(name string, check Check) {
+	c.add(namedCheck{name: name, check: check, required: true})
+}
+
+// AddOptional добавляет проверку, неудача которой переводит отчет в degraded,
+// но не снимает готовность
+func (c *Checker) AddOptional(name string, check Check) {
+	c.add(namedCheck{name: name, check: check})
+}
+
+func (c *Checker) add(check namedCheck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check)
+}
+
+// Run выполняет все проверки и собирает отчет
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check namedCheck) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusOK,
+		Service:   c.service,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult, len(checks)),
+	}
+
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.name] = result
+
+		switch {
+		case result.Status == StatusOK:
+		case check.required:
+			report.Status = StatusFail
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check namedCheck) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	details, err := check.check(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		Required:  check.required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
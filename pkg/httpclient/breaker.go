@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"biletter-service/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// Состояния circuit breaker'а; значения совпадают с метрикой circuit_breaker_state
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateHalfOpen
+	stateOpen
+)
+
+// breaker размыкается после failures ошибок подряд и не пропускает запросы timeout.
+// Затем пропускает один пробный запрос: успех замыкает breaker, ошибка снова размыкает.
+type breaker struct {
+	service  string
+	failures int
+	timeout  time.Duration
+
+	mu          sync.Mutex
+	state       breakerState
+	consecutive int
+	openedAt    time.Time
+	probing     bool
+}
+
+func newBreaker(service string, failures int, timeout time.Duration) *breaker {
+	metrics.CircuitBreakerState.WithLabelValues(service).Set(float64(stateClosed))
+	return &breaker{
+		service:  service,
+		failures: failures,
+		timeout:  timeout,
+	}
+}
+
+// allow сообщает, можно ли выполнить запрос
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.timeout {
+			return false
+		}
+		b.setState(stateHalfOpen)
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		// Пока пробный запрос не завершился, остальные запросы не пропускаются
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record учитывает результат запроса; failure - сетевая ошибка или ответ 5xx
+func (b *breaker) record(failure bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failure {
+		b.consecutive = 0
+		b.setState(stateClosed)
+		return
+	}
+
+	b.consecutive++
+	if b.state == stateHalfOpen || b.consecutive >= b.failures {
+		b.openedAt = time.Now()
+		b.setState(stateOpen)
+	}
+}
+
+// release завершает запрос, результат которого ничего не говорит о здоровье upstream'а
+// (например, запрос отменил вызывающий)
+func (b *breaker) release() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	metrics.CircuitBreakerState.WithLabelValues(b.service).Set(float64(state))
+}
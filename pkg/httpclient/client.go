@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"biletter-service/internal/config"
+	"biletter-service/pkg/logger"
+	"biletter-service/pkg/metrics"
+	"biletter-service/pkg/requestid"
+	"biletter-service/pkg/tracing"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Client клиент внешнего сервиса: таймаут на каждую попытку, повтор идемпотентных
+// запросов с экспоненциальной задержкой и джиттером, circuit breaker на upstream
+// и ошибки StatusError со статусом и телом ответа
+type Client struct {
+	service    string
+	baseURL    string
+	httpClient *http.Client
+	breaker    *breaker
+	config     config.HTTPClient
+}
+
+// New создает клиент upstream'а service с адресом baseURL. Исходящие запросы
+// несут X-Request-ID и trace context и попадают в метрики external_*.
+func New(service, baseURL string, cfg config.HTTPClient) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+		// Клиент ходит в один upstream, поэтому пул на хост равен общему
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+	}
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+
+	client := &Client{
+		service: service,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: metrics.InstrumentClient(tracing.InstrumentClient(requestid.InstrumentClient(&http.Client{
+			Transport: transport,
+		})), service),
+		config: cfg,
+	}
+	if cfg.BreakerFailures > 0 {
+		client.breaker = newBreaker(service, cfg.BreakerFailures, cfg.BreakerTimeout)
+	}
+
+	return client
+}
+
+// CallOption настраивает отдельный вызов
+type CallOption func(*call)
+
+type call struct {
+	idempotent bool
+	timeout    time.Duration
+	query      url.Values
+	header     http.Header
+}
+
+// Idempotent разрешает повторять POST/PATCH запрос, который безопасно выполнить дважды
+func Idempotent() CallOption {
+	return func(c *call) {
+		c.idempotent = true
+	}
+}
+
+// Timeout переопределяет таймаут одной попытки
+func Timeout(timeout time.Duration) CallOption {
+	return func(c *call) {
+		c.timeout = timeout
+	}
+}
+
+// Query добавляет параметры строки запроса
+func Query(query url.Values) CallOption {
+	return func(c *call) {
+		c.query = query
+	}
+}
+
+// Header добавляет заголовок запроса
+func Header(key, value string) CallOption {
+	return func(c *call) {
+		if c.header == nil {
+			c.header = make(http.Header)
+		}
+		c.header.Add(key, value)
+	}
+}
+
+// Do выполняет запрос method к path относительно адреса upstream'а. body (если не nil)
+// отправляется как JSON, ответ 2xx декодируется в out (если не nil).
+// GET, HEAD, PUT, DELETE и запросы с Idempotent повторяются при сетевых ошибках, 429 и 5xx.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any, opts ...CallOption) error {
+	settings := call{
+		idempotent: isIdempotent(method),
+		timeout:    c.config.Timeout,
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("%s: failed to marshal request: %w", c.service, err)
+		}
+	}
+
+	target := c.baseURL + path
+	if len(settings.query) > 0 {
+		target += "?" + settings.query.Encode()
+	}
+
+	attempts := 1
+	if settings.idempotent && c.config.MaxRetries > 0 {
+		attempts += c.config.MaxRetries
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if waitErr := c.wait(ctx, attempt); waitErr != nil {
+				return err
+			}
+			metrics.ExternalRetries.WithLabelValues(c.service).Inc()
+			logger.FromContext(ctx, zap.L()).Warn("Retrying external request",
+				zap.String("service", c.service),
+				zap.String("method", method),
+				zap.String("path", path),
+				zap.Int("attempt", attempt+1),
+				zap.Error(err))
+		}
+
+		err = c.attempt(ctx, method, target, payload, out, settings)
+		if err == nil || !retryable(ctx, err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// attempt выполняет одну попытку запроса с таймаутом попытки
+func (c *Client) attempt(ctx context.Context, method, target string, payload []byte, out any, settings call) error {
+	if !c.breaker.allow() {
+		return fmt.Errorf("%s: %w", c.service, ErrCircuitOpen)
+	}
+
+	parent := ctx
+	if settings.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		c.breaker.release()
+		return fmt.Errorf("%s: failed to create request: %w", c.service, err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range settings.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Отмена или дедлайн вызывающего не говорят о здоровье upstream'а, таймаут попытки - говорит
+		if parent.Err() != nil {
+			c.breaker.release()
+		} else {
+			c.breaker.record(true)
+		}
+		return fmt.Errorf("%s: failed to execute request: %w", c.service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		statusErr := &StatusError{
+			Service:    c.service,
+			Method:     method,
+			URL:        target,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(data)),
+		}
+		c.breaker.record(resp.StatusCode >= 500)
+		return statusErr
+	}
+	c.breaker.record(false)
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: failed to decode response: %w", c.service, err)
+	}
+
+	return nil
+}
+
+// wait ждет перед повтором: base * 2^(attempt-1), не больше max, со случайным джиттером
+// в пределах второй половины задержки
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	delay := c.config.RetryBaseDelay << (attempt - 1)
+	if c.config.RetryMaxDelay > 0 && (delay > c.config.RetryMaxDelay || delay <= 0) {
+		delay = c.config.RetryMaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryable сообщает, имеет ли смысл повторить запрос после ошибки
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+
+	// Breaker разомкнут до истечения таймаута, повторы в пределах запроса бесполезны
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	// Сетевые ошибки и таймаут попытки
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
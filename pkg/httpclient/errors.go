@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrCircuitOpen запрос не выполнялся: breaker upstream'а разомкнут
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// maxErrorBody ограничивает тело ответа, сохраняемое в StatusError
+const maxErrorBody = 4 << 10
+
+// StatusError upstream ответил неуспешным статусом
+type StatusError struct {
+	Service    string
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string // начало тела ответа, не больше maxErrorBody байт
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s: %s %s: unexpected status code: %d", e.Service, e.Method, e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s %s: unexpected status code: %d: %s", e.Service, e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// Temporary сообщает, что статус может смениться при повторе запроса
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		(e.StatusCode >= 500 && e.StatusCode != http.StatusNotImplemented)
+}
+
+// StatusCode возвращает статус ответа upstream'а из цепочки ошибок или 0
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
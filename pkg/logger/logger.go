@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -34,5 +36,22 @@ func New(level string) *zap.Logger {
 	}
 
 	logger, _ := config.Build()
+	// Глобальный логгер используется, когда в контексте нет логгера запроса
+	zap.ReplaceGlobals(logger)
 	return logger
 }
+
+type contextKey struct{}
+
+// NewContext сохраняет в контексте логгер запроса
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса или fallback, если в контексте его нет
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
+}
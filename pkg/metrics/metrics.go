@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "biletter"
+
+// HTTP сервер
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+)
+
+// Кеш
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "cache",
+	Name:      "requests_total",
+	Help:      "Cache lookups by cache name and result (hit, miss, error).",
+}, []string{"cache", "result"})
+
+// Брокер сообщений
+var (
+	BrokerPublishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "broker",
+		Name:      "publish_duration_seconds",
+		Help:      "Duration of event publishing by broker, topic and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"broker", "topic", "result"})
+
+	BrokerHandleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "broker",
+		Name:      "handle_duration_seconds",
+		Help:      "Duration of consumed event handling including in-process retries by event type and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type", "result"})
+
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consumer_lag",
+		Help:      "Messages between the last consumed offset and the partition high watermark.",
+	}, []string{"topic", "partition"})
+)
+
+// Внешние сервисы
+var (
+	ExternalRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "external",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of calls to external services by service, operation and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "operation", "result"})
+
+	ExternalRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "external",
+		Name:      "retries_total",
+		Help:      "Retried calls to external services by service.",
+	}, []string{"service"})
+
+	CircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "external",
+		Name:      "circuit_breaker_state",
+		Help:      "Circuit breaker state by service: 0 closed, 1 half-open, 2 open.",
+	}, []string{"service"})
+)
+
+// Бизнес-метрики бронирования
+var (
+	Seats = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "booking",
+		Name:      "seats_total",
+		Help:      "Seat state changes by action (selected, released, sold).",
+	}, []string{"action"})
+
+	Bookings = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "booking",
+		Name:      "bookings_total",
+		Help:      "Booking state changes by action (created, cancelled, confirmed, payment_failed).",
+	}, []string{"action"})
+)
+
+// Действия бизнес-метрик
+const (
+	SeatSelected = "selected"
+	SeatReleased = "released"
+	SeatSold     = "sold"
+
+	BookingCreated       = "created"
+	BookingCancelled     = "cancelled"
+	BookingConfirmed     = "confirmed"
+	BookingPaymentFailed = "payment_failed"
+)
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Register регистрирует collector; повторная регистрация такого же collector'а игнорируется
+func Register(collector prometheus.Collector) {
+	err := prometheus.Register(collector)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &alreadyRegistered) {
+		panic(err)
+	}
+}
+
+// RegisterDB экспортирует статистику пула соединений sql.DB
+func RegisterDB(db *sql.DB, name string) {
+	Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Result возвращает метку результата операции
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// ObserveCache учитывает обращение к кешу
+func ObserveCache(cache string, hit bool, err error) {
+	result := "miss"
+	switch {
+	case err != nil:
+		result = "error"
+	case hit:
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveKafkaLag обновляет отставание consumer'а в партиции
+func ObserveKafkaLag(topic string, partition int32, lag int64) {
+	KafkaConsumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+// idSegment сегменты пути, похожие на идентификаторы: числа и UUID
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// operation возвращает метку операции запроса: метод и путь с идентификаторами, замененными на :id
+func operation(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+// instrumentedTransport учитывает длительность и результат запросов к внешнему сервису
+type instrumentedTransport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// InstrumentClient добавляет к клиенту метрики внешнего сервиса service
+func InstrumentClient(client *http.Client, service string) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &instrumentedTransport{service: service, base: base}
+	return client
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	result := "error"
+	if err == nil {
+		result = strconv.Itoa(resp.StatusCode/100) + "xx"
+	}
+	ExternalRequestDuration.WithLabelValues(t.service, operation(req), result).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
@@ -0,0 +1,637 @@
+// Package paymentmock заглушка платежного шлюза для локального запуска и тестов
+// PaymentGatewayService и обработчика webhook'ов.
+package paymentmock
+
+import (
+	"biletter-service/internal/models"
+	"biletter-service/pkg/paymenttoken"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы платежа
+const (
+	StatusNew        = "NEW"
+	StatusAuthorized = "AUTHORIZED"
+	StatusConfirmed  = "CONFIRMED"
+	StatusFailed     = "FAILED"
+	StatusCancelled  = "CANCELLED"
+	StatusExpired    = "EXPIRED"
+)
+
+var (
+	// ErrPaymentNotFound платежа с таким ID нет
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrInvalidTransition платеж в текущем статусе не допускает операцию
+	ErrInvalidTransition = errors.New("invalid payment status")
+)
+
+// Options учетные данные команды и начальные настройки заглушки
+type Options struct {
+	TeamSlug   string       // ожидаемый teamSlug запросов
+	Password   string       // пароль для проверки токена
+	PublicURL  string       // адрес заглушки в paymentURL; по умолчанию берется из Host запроса
+	HTTPClient *http.Client // клиент для webhook'ов, по умолчанию с таймаутом 5s
+	Settings   Settings
+}
+
+// Settings исход оплаты и доставка webhook'ов
+type Settings struct {
+	Outcome             string        `json:"outcome"`              // исход оплаты: CONFIRMED (по умолчанию), AUTHORIZED или FAILED
+	AutoPay             bool          `json:"auto_pay"`             // оплачивать платеж сразу после init, без страницы оплаты
+	NotificationDelay   time.Duration `json:"notification_delay"`   // задержка перед отправкой webhook'а
+	NotificationRetries int           `json:"notification_retries"` // повторов webhook'а при ошибке доставки или ответе не 2xx
+}
+
+// Notification результат доставки webhook'а
+type Notification struct {
+	PaymentID  string    `json:"payment_id"`
+	Status     string    `json:"status"`
+	URL        string    `json:"url"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code"` // ответ последней попытки, 0 - ответа не было
+	Error      string    `json:"error,omitempty"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+type payment struct {
+	id        string
+	request   models.PaymentInitRequest
+	status    string
+	createdAt time.Time
+	updatedAt time.Time
+	expiresAt time.Time
+	expiry    *time.Timer
+}
+
+// Server реализует API шлюза (PaymentInit, PaymentCheck, PaymentConfirm, PaymentCancel)
+// с проверкой teamSlug и токена, страницу оплаты с редиректом на successURL/failURL
+// и асинхронные уведомления на notificationURL. Server - http.Handler,
+// поэтому встраивается в тесты через httptest.NewServer.
+type Server struct {
+	options Options
+	mux     *http.ServeMux
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	deliverWG sync.WaitGroup
+
+	mu            sync.Mutex
+	settings      Settings
+	payments      map[string]*payment
+	orderPayments map[string]string // orderId -> последний платеж заказа
+	notifications []Notification
+}
+
+// New создает заглушку без платежей
+func New(options Options) *Server {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	options.PublicURL = strings.TrimRight(options.PublicURL, "/")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		options: options,
+		mux:     http.NewServeMux(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	s.Reset()
+	if err := s.SetSettings(options.Settings); err != nil {
+		s.settings = Settings{Outcome: StatusConfirmed}
+	}
+
+	s.mux.HandleFunc("POST /PaymentInit/init", s.handleInit)
+	s.mux.HandleFunc("POST /PaymentCheck/check", s.handleCheck)
+	s.mux.HandleFunc("POST /PaymentConfirm/confirm", s.handleConfirm)
+	s.mux.HandleFunc("POST /PaymentCancel/cancel", s.handleCancel)
+
+	// Страница оплаты
+	s.mux.HandleFunc("GET /pay/{id}", s.handlePage)
+	s.mux.HandleFunc("POST /pay/{id}", s.handlePay)
+
+	// Управление заглушкой
+	s.mux.HandleFunc("POST /_mock/reset", s.handleReset)
+	s.mux.HandleFunc("GET /_mock/settings", s.handleGetSettings)
+	s.mux.HandleFunc("PUT /_mock/settings", s.handleSetSettings)
+	s.mux.HandleFunc("GET /_mock/notifications", s.handleNotifications)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close отменяет недоставленные webhook'и и ждет завершения отправки
+func (s *Server) Close() {
+	s.cancel()
+
+	s.mu.Lock()
+	for _, p := range s.payments {
+		p.expiry.Stop()
+	}
+	s.mu.Unlock()
+
+	s.deliverWG.Wait()
+}
+
+// Reset удаляет платежи и историю уведомлений
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.payments {
+		p.expiry.Stop()
+	}
+	s.payments = make(map[string]*payment)
+	s.orderPayments = make(map[string]string)
+	s.notifications = nil
+}
+
+// SetSettings меняет исход оплаты и доставку уведомлений для следующих платежей
+func (s *Server) SetSettings(settings Settings) error {
+	if settings.Outcome == "" {
+		settings.Outcome = StatusConfirmed
+	}
+	settings.Outcome = strings.ToUpper(settings.Outcome)
+	if !validOutcome(settings.Outcome) {
+		return fmt.Errorf("outcome must be %s, %s or %s", StatusConfirmed, StatusAuthorized, StatusFailed)
+	}
+	if settings.NotificationDelay < 0 || settings.NotificationRetries < 0 {
+		return errors.New("notification_delay and notification_retries must not be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	return nil
+}
+
+// Settings возвращает текущие настройки
+func (s *Server) Settings() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings
+}
+
+// PaymentStatus возвращает статус платежа или false, если платежа нет
+func (s *Server) PaymentStatus(paymentID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[paymentID]
+	if !ok {
+		return "", false
+	}
+	return p.status, true
+}
+
+// PaymentAmount возвращает сумму платежа из PaymentInit или false, если платежа нет
+func (s *Server) PaymentAmount(paymentID string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[paymentID]
+	if !ok {
+		return 0, false
+	}
+	return p.request.Amount, true
+}
+
+// Notifications возвращает завершенные доставки webhook'ов в порядке завершения
+func (s *Server) Notifications() []Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Notification(nil), s.notifications...)
+}
+
+// Pay проводит оплату NEW платежа с исходом outcome (пусто - из настроек),
+// как если бы покупатель прошел страницу оплаты
+func (s *Server) Pay(paymentID, outcome string) error {
+	_, err := s.pay(paymentID, outcome)
+	return err
+}
+
+func (s *Server) pay(paymentID, outcome string) (*payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if outcome == "" {
+		outcome = s.settings.Outcome
+	}
+	outcome = strings.ToUpper(outcome)
+	if !validOutcome(outcome) {
+		return nil, fmt.Errorf("unknown outcome %q", outcome)
+	}
+
+	p, ok := s.payments[paymentID]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	if p.status != StatusNew {
+		return nil, fmt.Errorf("%w: payment is %s", ErrInvalidTransition, p.status)
+	}
+
+	s.setStatus(p, outcome)
+	return p, nil
+}
+
+// setStatus меняет статус платежа и ставит в очередь webhook; вызывается под s.mu
+func (s *Server) setStatus(p *payment, status string) {
+	p.status = status
+	p.updatedAt = time.Now()
+	if status != StatusNew {
+		p.expiry.Stop()
+	}
+
+	if p.request.NotificationURL == "" {
+		return
+	}
+
+	payload := models.PaymentNotificationPayload{
+		PaymentID: p.id,
+		Status:    status,
+		TeamSlug:  p.request.TeamSlug,
+		Timestamp: p.updatedAt.UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"orderId":  p.request.OrderID,
+			"amount":   p.request.Amount,
+			"currency": p.request.Currency,
+		},
+	}
+
+	s.deliverWG.Add(1)
+	go s.deliver(p.request.NotificationURL, payload, s.settings)
+}
+
+// deliver отправляет webhook после задержки и повторяет его при ошибке
+func (s *Server) deliver(url string, payload models.PaymentNotificationPayload, settings Settings) {
+	defer s.deliverWG.Done()
+
+	notification := Notification{
+		PaymentID: payload.PaymentID,
+		Status:    payload.Status,
+		URL:       url,
+	}
+	body, _ := json.Marshal(payload)
+
+	delay := settings.NotificationDelay
+	for attempt := 0; attempt <= settings.NotificationRetries; attempt++ {
+		if attempt > 0 && delay < time.Second {
+			delay = time.Second
+		}
+		if !s.sleep(delay) {
+			return
+		}
+
+		notification.Attempts++
+		notification.StatusCode, notification.Error = s.post(url, body)
+		if notification.Error == "" && notification.StatusCode >= 200 && notification.StatusCode < 300 {
+			break
+		}
+	}
+	notification.SentAt = time.Now()
+
+	s.mu.Lock()
+	s.notifications = append(s.notifications, notification)
+	s.mu.Unlock()
+}
+
+func (s *Server) post(url string, body []byte) (int, string) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.options.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	resp.Body.Close()
+	return resp.StatusCode, ""
+}
+
+// sleep ждет delay; false - заглушка закрыта
+func (s *Server) sleep(delay time.Duration) bool {
+	if delay <= 0 {
+		return s.ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// handleInit создает платеж в статусе NEW; при AutoPay он сразу оплачивается
+func (s *Server) handleInit(w http.ResponseWriter, r *http.Request) {
+	var request models.PaymentInitRequest
+	if !s.decode(w, r, &request, &request.TeamSlug, &request.Token) {
+		return
+	}
+	if request.OrderID == "" || request.Amount <= 0 || request.Currency == "" {
+		writeError(w, http.StatusBadRequest, "orderId, positive amount and currency are required")
+		return
+	}
+
+	now := time.Now()
+	p := &payment{
+		id:        uuid.NewString(),
+		request:   request,
+		status:    StatusNew,
+		createdAt: now,
+		updatedAt: now,
+	}
+	expiry := time.Duration(request.PaymentExpiry) * time.Second
+	if expiry <= 0 {
+		expiry = time.Hour
+	}
+	p.expiresAt = now.Add(expiry)
+
+	s.mu.Lock()
+	s.payments[p.id] = p
+	s.orderPayments[request.OrderID] = p.id
+	p.expiry = time.AfterFunc(expiry, func() { s.expire(p.id) })
+	autoPay := s.settings.AutoPay
+	if autoPay {
+		s.setStatus(p, s.settings.Outcome)
+	}
+	status := p.status
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, models.PaymentInitResponse{
+		Success:    success(),
+		PaymentID:  p.id,
+		OrderID:    request.OrderID,
+		Status:     status,
+		Amount:     request.Amount,
+		Currency:   request.Currency,
+		PaymentURL: s.publicURL(r) + "/pay/" + p.id,
+		ExpiresAt:  p.expiresAt.UTC().Format(time.RFC3339),
+		CreatedAt:  p.createdAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// expire переводит неоплаченный платеж в EXPIRED по истечении paymentExpiry
+func (s *Server) expire(paymentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.payments[paymentID]; ok && p.status == StatusNew {
+		s.setStatus(p, StatusExpired)
+	}
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	var request models.PaymentCheckRequest
+	if !s.decode(w, r, &request, &request.TeamSlug, &request.Token) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paymentID := request.PaymentID
+	if paymentID == "" {
+		paymentID = s.orderPayments[request.OrderID]
+	}
+	p, ok := s.payments[paymentID]
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrPaymentNotFound.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.PaymentCheckResponse{
+		Success:   success(),
+		PaymentID: p.id,
+		OrderID:   p.request.OrderID,
+		Status:    p.status,
+		Amount:    p.request.Amount,
+		Currency:  p.request.Currency,
+		CreatedAt: p.createdAt.UTC().Format(time.RFC3339),
+		UpdatedAt: p.updatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// handleConfirm AUTHORIZED -> CONFIRMED; сумма не больше авторизованной
+func (s *Server) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	var request models.PaymentConfirmRequest
+	if !s.decode(w, r, &request, &request.TeamSlug, &request.Token) {
+		return
+	}
+
+	s.transition(w, request.PaymentID, "Payment confirmed", func(p *payment) error {
+		if p.status != StatusAuthorized {
+			return fmt.Errorf("payment is %s", p.status)
+		}
+		if request.Amount <= 0 || request.Amount > p.request.Amount {
+			return fmt.Errorf("amount must be between 1 and %d", p.request.Amount)
+		}
+		s.setStatus(p, StatusConfirmed)
+		return nil
+	})
+}
+
+// handleCancel NEW/AUTHORIZED -> CANCELLED
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	var request models.PaymentCancelRequest
+	if !s.decode(w, r, &request, &request.TeamSlug, &request.Token) {
+		return
+	}
+
+	s.transition(w, request.PaymentID, "Payment cancelled", func(p *payment) error {
+		if p.status != StatusNew && p.status != StatusAuthorized {
+			return fmt.Errorf("payment is %s", p.status)
+		}
+		s.setStatus(p, StatusCancelled)
+		return nil
+	})
+}
+
+// transition применяет переход к платежу; ошибка перехода - 409
+func (s *Server) transition(w http.ResponseWriter, paymentID, message string, apply func(p *payment) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[paymentID]
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrPaymentNotFound.Error())
+		return
+	}
+	if err := apply(p); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.PaymentConfirmResponse{
+		Success:   success(),
+		PaymentID: p.id,
+		Status:    p.status,
+		Message:   message,
+	})
+}
+
+// decode читает запрос и проверяет teamSlug и токен так же, как их считает клиент шлюза
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, request any, teamSlug, token *string) bool {
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	if *teamSlug != s.options.TeamSlug {
+		writeError(w, http.StatusUnauthorized, "unknown teamSlug")
+		return false
+	}
+	if *token != paymenttoken.Generate(request, s.options.Password) {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return false
+	}
+	return true
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Оплата заказа {{.OrderID}}</title></head>
+<body>
+<h1>Оплата заказа {{.OrderID}}</h1>
+<p>{{.Description}}</p>
+<p>Сумма: {{.Amount}} {{.Currency}}</p>
+<p>Статус: {{.Status}}</p>
+{{if .Payable}}
+<form method="post"><input type="hidden" name="outcome" value="{{.Outcome}}"><button type="submit">Оплатить</button></form>
+<form method="post"><input type="hidden" name="outcome" value="FAILED"><button type="submit">Отклонить</button></form>
+{{end}}
+</body>
+</html>
+`))
+
+// handlePage страница оплаты: оплата или отказ
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	p, ok := s.payments[r.PathValue("id")]
+	if !ok {
+		s.mu.Unlock()
+		http.Error(w, ErrPaymentNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	outcome := s.settings.Outcome
+	if outcome == StatusFailed {
+		outcome = StatusConfirmed
+	}
+	data := map[string]any{
+		"OrderID":     p.request.OrderID,
+		"Description": p.request.Description,
+		"Amount":      p.request.Amount,
+		"Currency":    p.request.Currency,
+		"Status":      p.status,
+		"Payable":     p.status == StatusNew,
+		"Outcome":     outcome,
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	pageTemplate.Execute(w, data)
+}
+
+// handlePay проводит оплату с исходом из формы (пусто - из настроек)
+// и перенаправляет покупателя на successURL или failURL
+func (s *Server) handlePay(w http.ResponseWriter, r *http.Request) {
+	p, err := s.pay(r.PathValue("id"), r.FormValue("outcome"))
+	switch {
+	case errors.Is(err, ErrPaymentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, ErrInvalidTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	target := p.request.SuccessURL
+	if p.status == StatusFailed {
+		target = p.request.FailURL
+	}
+	s.mu.Unlock()
+
+	if target == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	s.Reset()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Settings())
+}
+
+func (s *Server) handleSetSettings(w http.ResponseWriter, r *http.Request) {
+	var settings Settings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.SetSettings(settings); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Settings())
+}
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Notifications())
+}
+
+func (s *Server) publicURL(r *http.Request) string {
+	if s.options.PublicURL != "" {
+		return s.options.PublicURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func validOutcome(outcome string) bool {
+	return outcome == StatusConfirmed || outcome == StatusAuthorized || outcome == StatusFailed
+}
+
+func success() *bool {
+	ok := true
+	return &ok
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"success": false, "message": message})
+}
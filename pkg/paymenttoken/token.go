@@ -0,0 +1,87 @@
+// Package paymenttoken подпись запросов платежного шлюза. Используется клиентом шлюза
+// и заглушкой шлюза, поэтому обе стороны считают токен одинаково.
+package paymenttoken
+
+import (
+	"biletter-service/internal/models"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Generate возвращает токен запроса: значения подписываемых полей в алфавитном порядке
+// ключей, к ним дописан пароль, от строки берется SHA-256 в hex
+func Generate(request interface{}, password string) string {
+	// Извлекаем поля для генерации токена в алфавитном порядке
+	params := make(map[string]string)
+
+	switch req := request.(type) {
+	case *models.PaymentInitRequest:
+		params["amount"] = fmt.Sprintf("%d", req.Amount)
+		params["currency"] = req.Currency
+		params["orderId"] = req.OrderID
+		params["teamSlug"] = req.TeamSlug
+	case *models.PaymentCheckRequest:
+		if req.PaymentID != "" {
+			params["paymentId"] = req.PaymentID
+		}
+		if req.OrderID != "" {
+			params["orderId"] = req.OrderID
+		}
+		params["teamSlug"] = req.TeamSlug
+	case *models.PaymentConfirmRequest:
+		params["amount"] = fmt.Sprintf("%d", req.Amount)
+		params["paymentId"] = req.PaymentID
+		params["teamSlug"] = req.TeamSlug
+	case *models.PaymentCancelRequest:
+		params["paymentId"] = req.PaymentID
+		params["teamSlug"] = req.TeamSlug
+	default:
+		// Generic reflection-based approach
+		v := reflect.ValueOf(request).Elem()
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			value := v.Field(i)
+
+			// Пропускаем поле Token
+			if field.Name == "Token" {
+				continue
+			}
+
+			jsonTag := field.Tag.Get("json")
+			if jsonTag == "" || jsonTag == "-" {
+				continue
+			}
+
+			// Убираем omitempty из json тега
+			fieldName, _, _ := strings.Cut(jsonTag, ",")
+
+			if value.IsValid() && !value.IsZero() {
+				params[fieldName] = fmt.Sprintf("%v", value.Interface())
+			}
+		}
+	}
+
+	// Сортируем ключи в алфавитном порядке
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Конкатенируем значения в алфавитном порядке ключей
+	var tokenString strings.Builder
+	for _, key := range keys {
+		tokenString.WriteString(params[key])
+	}
+
+	// Добавляем пароль
+	tokenString.WriteString(password)
+
+	// Генерируем SHA-256 хеш
+	hash := sha256.Sum256([]byte(tokenString.String()))
+	return fmt.Sprintf("%x", hash)
+}
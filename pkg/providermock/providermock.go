@@ -0,0 +1,450 @@
+// Package providermock заглушка партнерского API провайдера мероприятий Hackload
+// для локального запуска и тестов EventProviderService.
+package providermock
+
+import (
+	"biletter-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы заказа провайдера
+const (
+	OrderStarted   = "STARTED"
+	OrderSubmitted = "SUBMITTED"
+	OrderConfirmed = "CONFIRMED"
+	OrderCancelled = "CANCELLED"
+)
+
+// Options схема зала и версия API заглушки
+type Options struct {
+	APIVersion  string // версия в пути /api/partners/{version}, по умолчанию v1
+	Rows        int    // рядов в зале, по умолчанию 10
+	SeatsPerRow int    // мест в ряду, по умолчанию 20
+	Faults      Faults
+}
+
+// Faults задержка и отказы, добавляемые к ответам партнерского API
+type Faults struct {
+	Latency       time.Duration `json:"latency"`        // задержка каждого ответа
+	LatencyJitter time.Duration `json:"latency_jitter"` // случайная добавка к задержке, до LatencyJitter
+	FailureRate   float64       `json:"failure_rate"`   // доля запросов, завершаемых FailureStatus (0..1)
+	FailureStatus int           `json:"failure_status"` // по умолчанию 503
+}
+
+type place struct {
+	models.Place
+	orderID string // заказ, выбравший место; пусто - место свободно
+}
+
+type order struct {
+	id        string
+	status    string
+	startedAt time.Time
+	updatedAt time.Time
+	places    map[string]struct{}
+}
+
+// Server реализует партнерский API: заказы (создание, submit, confirm, cancel)
+// и места (список, выбор, освобождение) с переходами состояний как у провайдера.
+// Server - http.Handler, поэтому встраивается в тесты через httptest.NewServer.
+type Server struct {
+	options Options
+	mux     *http.ServeMux
+
+	mu         sync.Mutex
+	faults     Faults
+	places     map[string]*place
+	placeOrder []string // ID мест в порядке ряд/место для постраничной выдачи
+	orders     map[string]*order
+}
+
+// New создает заглушку со свободным залом
+func New(options Options) *Server {
+	if options.APIVersion == "" {
+		options.APIVersion = "v1"
+	}
+	if options.Rows <= 0 {
+		options.Rows = 10
+	}
+	if options.SeatsPerRow <= 0 {
+		options.SeatsPerRow = 20
+	}
+
+	s := &Server{
+		options: options,
+		mux:     http.NewServeMux(),
+	}
+	s.Reset()
+	s.SetFaults(options.Faults)
+
+	prefix := "/api/partners/" + options.APIVersion
+	s.mux.HandleFunc("POST "+prefix+"/orders", s.withFaults(s.createOrder))
+	s.mux.HandleFunc("GET "+prefix+"/orders/{id}", s.withFaults(s.getOrder))
+	s.mux.HandleFunc("PATCH "+prefix+"/orders/{id}/submit", s.withFaults(s.submitOrder))
+	s.mux.HandleFunc("PATCH "+prefix+"/orders/{id}/confirm", s.withFaults(s.confirmOrder))
+	s.mux.HandleFunc("PATCH "+prefix+"/orders/{id}/cancel", s.withFaults(s.cancelOrder))
+	s.mux.HandleFunc("GET "+prefix+"/places", s.withFaults(s.listPlaces))
+	s.mux.HandleFunc("GET "+prefix+"/places/{id}", s.withFaults(s.getPlace))
+	s.mux.HandleFunc("PATCH "+prefix+"/places/{id}/select", s.withFaults(s.selectPlace))
+	s.mux.HandleFunc("PATCH "+prefix+"/places/{id}/release", s.withFaults(s.releasePlace))
+
+	// Управление заглушкой: без задержек и отказов
+	s.mux.HandleFunc("POST /_mock/reset", s.handleReset)
+	s.mux.HandleFunc("GET /_mock/faults", s.handleGetFaults)
+	s.mux.HandleFunc("PUT /_mock/faults", s.handleSetFaults)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Reset освобождает все места и удаляет заказы; ID мест генерируются заново
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.places = make(map[string]*place, s.options.Rows*s.options.SeatsPerRow)
+	s.placeOrder = make([]string, 0, s.options.Rows*s.options.SeatsPerRow)
+	s.orders = make(map[string]*order)
+
+	for row := 1; row <= s.options.Rows; row++ {
+		for seat := 1; seat <= s.options.SeatsPerRow; seat++ {
+			id := uuid.NewString()
+			s.places[id] = &place{Place: models.Place{ID: id, Row: row, Seat: seat, IsFree: true}}
+			s.placeOrder = append(s.placeOrder, id)
+		}
+	}
+}
+
+// SetFaults меняет задержку и долю отказов для следующих запросов
+func (s *Server) SetFaults(faults Faults) {
+	if faults.FailureStatus == 0 {
+		faults.FailureStatus = http.StatusServiceUnavailable
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = faults
+}
+
+// Faults возвращает текущие настройки отказов
+func (s *Server) Faults() Faults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.faults
+}
+
+// Places возвращает снимок всех мест в порядке ряд/место
+func (s *Server) Places() []models.Place {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	places := make([]models.Place, 0, len(s.placeOrder))
+	for _, id := range s.placeOrder {
+		places = append(places, s.places[id].Place)
+	}
+	return places
+}
+
+// OrderStatus возвращает статус заказа или false, если заказа нет
+func (s *Server) OrderStatus(orderID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok {
+		return "", false
+	}
+	return o.status, true
+}
+
+// withFaults добавляет к обработчику настроенную задержку и случайные отказы
+func (s *Server) withFaults(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		faults := s.Faults()
+
+		delay := faults.Latency
+		if faults.LatencyJitter > 0 {
+			delay += rand.N(faults.LatencyJitter)
+		}
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		if faults.FailureRate > 0 && rand.Float64() < faults.FailureRate {
+			writeError(w, faults.FailureStatus, "injected failure")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	o := &order{
+		id:        uuid.NewString(),
+		status:    OrderStarted,
+		startedAt: now,
+		updatedAt: now,
+		places:    make(map[string]struct{}),
+	}
+
+	s.mu.Lock()
+	s.orders[o.id] = o
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, models.CreateOrderResponse{OrderID: o.id})
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, o.details())
+}
+
+// submitOrder STARTED -> SUBMITTED; заказ без мест отправить нельзя
+func (s *Server) submitOrder(w http.ResponseWriter, r *http.Request) {
+	s.transition(w, r, func(o *order) error {
+		if o.status != OrderStarted {
+			return fmt.Errorf("order is %s", o.status)
+		}
+		if len(o.places) == 0 {
+			return fmt.Errorf("order has no places")
+		}
+		o.status = OrderSubmitted
+		return nil
+	})
+}
+
+// confirmOrder SUBMITTED -> CONFIRMED; места остаются занятыми
+func (s *Server) confirmOrder(w http.ResponseWriter, r *http.Request) {
+	s.transition(w, r, func(o *order) error {
+		if o.status != OrderSubmitted {
+			return fmt.Errorf("order is %s", o.status)
+		}
+		o.status = OrderConfirmed
+		return nil
+	})
+}
+
+// cancelOrder STARTED/SUBMITTED -> CANCELLED с освобождением мест
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	s.transition(w, r, func(o *order) error {
+		if o.status != OrderStarted && o.status != OrderSubmitted {
+			return fmt.Errorf("order is %s", o.status)
+		}
+		for placeID := range o.places {
+			s.free(s.places[placeID])
+		}
+		o.status = OrderCancelled
+		return nil
+	})
+}
+
+// transition применяет переход к заказу; ошибка перехода - 409
+func (s *Server) transition(w http.ResponseWriter, r *http.Request, apply func(o *order) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+
+	if err := apply(o); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	o.updatedAt = time.Now()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) listPlaces(w http.ResponseWriter, r *http.Request) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, "invalid page")
+		return
+	}
+	pageSize, err := queryInt(r, "pageSize", 20)
+	if err != nil || pageSize < 1 {
+		writeError(w, http.StatusBadRequest, "invalid pageSize")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	places := []models.Place{}
+	for i := (page - 1) * pageSize; i < len(s.placeOrder) && len(places) < pageSize; i++ {
+		places = append(places, s.places[s.placeOrder[i]].Place)
+	}
+
+	writeJSON(w, http.StatusOK, places)
+}
+
+func (s *Server) getPlace(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.places[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "place not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p.Place)
+}
+
+// selectPlace занимает свободное место заказом в статусе STARTED.
+// Повторный выбор места тем же заказом успешен.
+func (s *Server) selectPlace(w http.ResponseWriter, r *http.Request) {
+	var request models.SelectPlaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.OrderID == "" {
+		writeError(w, http.StatusBadRequest, "orderId is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.places[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "place not found")
+		return
+	}
+	o, ok := s.orders[request.OrderID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+	if o.status != OrderStarted {
+		writeError(w, http.StatusConflict, fmt.Sprintf("order is %s", o.status))
+		return
+	}
+
+	switch p.orderID {
+	case o.id:
+	case "":
+		p.orderID = o.id
+		p.IsFree = false
+		o.places[p.ID] = struct{}{}
+		o.updatedAt = time.Now()
+	default:
+		writeError(w, http.StatusConflict, "place is already selected")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// releasePlace освобождает место заказа в статусе STARTED. Освобождение свободного места успешно,
+// места отправленного или подтвержденного заказа освободить нельзя.
+func (s *Server) releasePlace(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.places[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "place not found")
+		return
+	}
+
+	if p.orderID != "" {
+		o := s.orders[p.orderID]
+		if o.status != OrderStarted {
+			writeError(w, http.StatusConflict, fmt.Sprintf("order is %s", o.status))
+			return
+		}
+		s.free(p)
+		o.updatedAt = time.Now()
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// free освобождает место; вызывается под s.mu
+func (s *Server) free(p *place) {
+	if o, ok := s.orders[p.orderID]; ok {
+		delete(o.places, p.ID)
+	}
+	p.orderID = ""
+	p.IsFree = true
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	s.Reset()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleGetFaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Faults())
+}
+
+func (s *Server) handleSetFaults(w http.ResponseWriter, r *http.Request) {
+	var faults Faults
+	if err := json.NewDecoder(r.Body).Decode(&faults); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if faults.FailureRate < 0 || faults.FailureRate > 1 {
+		writeError(w, http.StatusBadRequest, "failure_rate must be between 0 and 1")
+		return
+	}
+
+	s.SetFaults(faults)
+	writeJSON(w, http.StatusOK, s.Faults())
+}
+
+func (o *order) details() models.OrderDetails {
+	return models.OrderDetails{
+		ID:          o.id,
+		Status:      o.status,
+		StartedAt:   o.startedAt.UnixMilli(),
+		UpdatedAt:   o.updatedAt.UnixMilli(),
+		PlacesCount: len(o.places),
+	}
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
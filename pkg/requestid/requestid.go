@@ -0,0 +1,76 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Header заголовок HTTP запроса и ответа с идентификатором запроса
+const Header = "X-Request-ID"
+
+// MessageHeader заголовок сообщения брокера с идентификатором запроса, породившего событие.
+// Имя без префикса x-, поэтому заголовок сохраняется при пересылке в retry/DLQ и при replay.
+const MessageHeader = "request_id"
+
+// maxLength ограничивает длину идентификатора, принятого от клиента
+const maxLength = 128
+
+type contextKey struct{}
+
+// New создает новый идентификатор запроса
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid сообщает, можно ли принять идентификатор от клиента
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext сохраняет идентификатор запроса в контексте
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или пустую строку
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// transport передает идентификатор запроса из контекста во внешний сервис
+type transport struct {
+	base http.RoundTripper
+}
+
+// InstrumentClient добавляет к исходящим запросам клиента заголовок X-Request-ID
+func InstrumentClient(client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &transport{base: base}
+	return client
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrip не должен изменять исходный запрос
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return t.base.RoundTrip(req)
+}